@@ -5,10 +5,13 @@ import (
 	"embed"
 	"github.com/BurntSushi/toml"
 	"github.com/L11R/masked-email-bot/internal/domain"
+	"github.com/L11R/masked-email-bot/internal/infra/breaches"
 	"github.com/L11R/masked-email-bot/internal/infra/fastmail"
 	"github.com/L11R/masked-email-bot/internal/infra/httpserver"
+	"github.com/L11R/masked-email-bot/internal/infra/scheduler"
 	"github.com/L11R/masked-email-bot/internal/infra/sqlite"
 	"github.com/L11R/masked-email-bot/internal/infra/telegram"
+	"github.com/L11R/masked-email-bot/internal/infra/unsubscribe"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"github.com/sethvargo/go-envconfig"
 	"go.uber.org/zap"
@@ -17,13 +20,19 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	// Embed the timezone database for per-user digest schedules
+	_ "time/tzdata"
 )
 
 type Config struct {
-	TelegramConfig *telegram.Config
-	HTTPConfig     *httpserver.Config
-	FastmailConfig *fastmail.Config
-	DatabaseConfig *sqlite.Config
+	TelegramConfig    *telegram.Config
+	HTTPConfig        *httpserver.Config
+	FastmailConfig    *fastmail.Config
+	DatabaseConfig    *sqlite.Config
+	UnsubscribeConfig *unsubscribe.Config
+	SchedulerConfig   *scheduler.Config
+	BreachesConfig    *breaches.Config
+	ServiceConfig     *domain.Config
 }
 
 //go:embed locales/*.toml
@@ -49,6 +58,12 @@ func main() {
 	// Init Fastmail adapter
 	fmc := fastmail.NewAdapter(logger, c.FastmailConfig)
 
+	// Init unsubscribe adapter
+	unsubscriber := unsubscribe.NewAdapter(logger, c.UnsubscribeConfig)
+
+	// Init breaches adapter
+	breachesAdapter := breaches.NewAdapter(logger, c.BreachesConfig)
+
 	// Internalization (i18n)
 	bundle := i18n.NewBundle(language.English)
 	bundle.RegisterUnmarshalFunc("toml", toml.Unmarshal)
@@ -65,19 +80,19 @@ func main() {
 	})
 
 	// Init Telegram adapter
-	t, err := telegram.NewAdapter(logger, c.TelegramConfig, bundle)
+	t, err := telegram.NewAdapter(logger, c.TelegramConfig, bundle, db)
 	if err != nil {
 		logger.Fatal("Cannot init Telegram adapter!", zap.Error(err))
 	}
 
 	// Init service
-	service := domain.NewService(logger, db, fmc, t)
+	service := domain.NewService(logger, c.ServiceConfig, db, fmc, t, unsubscriber, breachesAdapter)
 
 	// Setup graceful shutdown
 	shutdown := make(chan error, 1)
 
 	// Init Telegram delivery
-	telegramDelivery, err := telegram.NewDelivery(logger, c.TelegramConfig, bundle, service)
+	telegramDelivery, err := telegram.NewDelivery(logger, c.TelegramConfig, bundle, db, db, service)
 	if err != nil {
 		logger.Fatal("Cannot init Telegram delivery!", zap.Error(err))
 	}
@@ -96,6 +111,16 @@ func main() {
 		shutdown <- httpDelivery.ListenAndServe()
 	}(shutdown)
 
+	// Init scheduler delivery
+	schedulerDelivery, err := scheduler.NewDelivery(logger, c.SchedulerConfig, service)
+	if err != nil {
+		logger.Fatal("Cannot init scheduler delivery!", zap.Error(err))
+	}
+
+	go func(shutdown chan<- error) {
+		shutdown <- schedulerDelivery.ListenAndServe()
+	}(shutdown)
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 
@@ -103,6 +128,7 @@ func main() {
 	case s := <-sig:
 		logger.Info("Got the signal!", zap.String("signal", s.String()))
 		telegramDelivery.Shutdown(nil)
+		schedulerDelivery.Shutdown(nil)
 		db.Close()
 	case err := <-shutdown:
 		logger.Error("Error running the application!", zap.Error(err))
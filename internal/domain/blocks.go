@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// normalizeDomain turns a domain or URL into the base domain used for blocking.
+func normalizeDomain(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+
+	host := raw
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", ErrInvalidDomain
+		}
+		host = u.Hostname()
+	}
+
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || !strings.Contains(host, ".") || strings.ContainsAny(host, " /:@") {
+		return "", ErrInvalidDomain
+	}
+
+	// Blocking co.uk would take down every site registered under it
+	if isPublicSuffix(host) {
+		return "", ErrInvalidDomain
+	}
+
+	return baseDomain(host), nil
+}
+
+func (s *service) checkDomainBlocked(telegramID int64, host string) error {
+	if host == "" {
+		return nil
+	}
+
+	blocked, err := s.db.IsDomainBlocked(telegramID, baseDomain(host))
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		return ErrDomainBlocked
+	}
+
+	return nil
+}
+
+// BlockDomain saves the domain to the block list and disables every masked email created for it.
+func (s *service) BlockDomain(telegramID int64, domain string) (*BlockedDomain, *BatchResult, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blockedDomain := &BlockedDomain{
+		Domain:    domain,
+		BlockedAt: time.Now().UTC(),
+	}
+	if err := s.db.BlockDomain(telegramID, blockedDomain.Domain, blockedDomain.BlockedAt); err != nil {
+		return nil, nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0)
+	for _, maskedEmail := range maskedEmails {
+		if maskedEmail.ForDomain == "" || baseDomain(forDomainHost(maskedEmail.ForDomain)) != domain {
+			continue
+		}
+
+		if !maskedEmail.IsActive() {
+			continue
+		}
+
+		ids = append(ids, maskedEmail.ID)
+	}
+
+	if len(ids) == 0 {
+		return blockedDomain, &BatchResult{}, nil
+	}
+
+	result, err := s.setMaskedEmailsState(ctx, tokenSrc, telegramID, maskedEmails, ids, MaskedEmailStateDisabled)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blockedDomain, result, nil
+}
+
+func (s *service) UnblockDomain(telegramID int64, domain string) error {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+
+	return s.db.UnblockDomain(telegramID, domain)
+}
+
+func (s *service) BlockedDomains(telegramID int64) ([]*BlockedDomain, error) {
+	return s.db.GetBlockedDomains(telegramID)
+}
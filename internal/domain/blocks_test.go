@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBaseDomain(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"example.com", "example.com"},
+		{"mail.shop.example.com", "example.com"},
+		{"Shop.Example.COM.", "example.com"},
+		{"shop.example.co.uk", "example.co.uk"},
+		{"bbc.co.uk", "bbc.co.uk"},
+		{"news.bbc.co.uk", "bbc.co.uk"},
+		{"user.github.io", "user.github.io"},
+		{"co.uk", "co.uk"},
+		{"localhost", "localhost"},
+		{"127.0.0.1", "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		if got := baseDomain(tt.host); got != tt.want {
+			t.Errorf("baseDomain(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		err  error
+	}{
+		{"example.com", "example.com", nil},
+		{" https://shop.example.co.uk/login ", "example.co.uk", nil},
+		{"shop.example.com.au", "example.com.au", nil},
+		{"co.uk", "", ErrInvalidDomain},
+		{"com", "", ErrInvalidDomain},
+		{"github.io", "", ErrInvalidDomain},
+		{"https://co.uk/", "", ErrInvalidDomain},
+		{"", "", ErrInvalidDomain},
+		{"user@example.com", "", ErrInvalidDomain},
+		{"exa mple.com", "", ErrInvalidDomain},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeDomain(tt.raw)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("normalizeDomain(%q) = %q, %v, want %q, %v", tt.raw, got, err, tt.want, tt.err)
+		}
+	}
+}
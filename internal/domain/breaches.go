@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// breachedMaskedEmails returns masked emails that existed and were in use when the breach happened.
+func breachedMaskedEmails(breach *Breach, maskedEmails []*MaskedEmail) []*MaskedEmail {
+	breached := make([]*MaskedEmail, 0)
+	for _, maskedEmail := range maskedEmails {
+		if maskedEmail.ForDomain == "" {
+			continue
+		}
+
+		if !maskedEmail.IsActive() {
+			continue
+		}
+
+		if baseDomain(forDomainHost(maskedEmail.ForDomain)) != baseDomain(breach.Domain) {
+			continue
+		}
+
+		// Addresses created after the breach couldn't have leaked in it
+		if !maskedEmail.CreatedAt.IsZero() && !breach.BreachDate.IsZero() &&
+			maskedEmail.CreatedAt.After(breach.BreachDate.Add(24*time.Hour)) {
+			continue
+		}
+
+		breached = append(breached, maskedEmail)
+	}
+
+	sort.Slice(breached, func(i, j int) bool {
+		return breached[i].Email < breached[j].Email
+	})
+
+	return breached
+}
+
+func (s *service) checkBreaches(ctx context.Context, user *User, breaches []*Breach) error {
+	tokenSrc, err := s.tokenSource(ctx, user.TelegramID)
+	if err != nil {
+		return err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return err
+	}
+
+	for _, breach := range breaches {
+		breached := breachedMaskedEmails(breach, maskedEmails)
+		if len(breached) == 0 {
+			continue
+		}
+
+		notified, err := s.db.IsBreachNotified(user.TelegramID, breach.Name)
+		if err != nil {
+			return err
+		}
+		if notified {
+			continue
+		}
+
+		if err := s.telegram.SendBreachAlert(user.TelegramID, user.LanguageCode, &BreachAlert{
+			Breach:       breach,
+			MaskedEmails: breached,
+		}); err != nil {
+			return err
+		}
+
+		if err := s.db.SaveNotifiedBreach(user.TelegramID, breach.Name, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) CheckBreaches() error {
+	breaches, err := s.breaches.GetBreaches()
+	if err != nil {
+		return err
+	}
+
+	if len(breaches) == 0 {
+		return nil
+	}
+
+	users, err := s.db.GetAuthorizedUsers()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, user := range users {
+		if err := s.checkBreaches(ctx, user, breaches); err != nil {
+			s.logger.Error(
+				"Error while checking breaches!",
+				zap.Int64("telegram_id", user.TelegramID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// RotateMaskedEmail creates a replacement masked email for the same site and disables the old one,
+// the disable can be undone. The replacement is returned with ErrRotationIncomplete when the old address couldn't be disabled.
+func (s *service) RotateMaskedEmail(telegramID int64, id string) (*MaskedEmail, *BatchResult, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	old, err := s.email.GetMaskedEmail(ctx, tokenSrc, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.withLabels(telegramID, []*MaskedEmail{old}); err != nil {
+		return nil, nil, err
+	}
+
+	// The replacement takes over right away, a pending one would be deleted before the site sends anything
+	create := &MaskedEmailCreate{
+		State:       MaskedEmailStateEnabled,
+		Description: old.Description,
+		URL:         old.URL,
+		EmailPrefix: old.EmailPrefix,
+	}
+	if u, err := url.Parse(old.ForDomain); err == nil && u.Host != "" {
+		u = SiteOrigin(u)
+		create.ForDomain = u.String()
+		// A prefix picked by the user is kept, the site one only fills in a missing prefix
+		if create.EmailPrefix == "" {
+			create.EmailPrefix = SiteEmailPrefix(u)
+		}
+	}
+
+	maskedEmail, err := s.createMaskedEmail(telegramID, create, old.Labels, false, old.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := s.updateMaskedEmails(
+		ctx,
+		tokenSrc,
+		telegramID,
+		map[string]*MaskedEmailUpdate{id: {State: MaskedEmailStateDisabled}},
+		map[string]*MaskedEmail{id: old},
+	)
+	if err == nil && len(result.Succeeded) == 0 {
+		err = ErrFastmailInternal
+	}
+	if err != nil {
+		s.logger.Error("Error while disabling a rotated masked email!", zap.String("masked_email_id", id), zap.Error(err))
+		return maskedEmail, nil, ErrRotationIncomplete
+	}
+
+	s.recordEvent(telegramID, id, MaskedEmailEventRotated, maskedEmail.Email)
+	s.notifyForumTopic(telegramID, id, stateTopicMessageIDs[MaskedEmailStateDisabled])
+
+	return maskedEmail, result, nil
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+// MaskedEmails returns masked emails matching the filter with their labels, sorted by address.
+func (s *service) MaskedEmails(telegramID int64, filter *MaskedEmailFilter) ([]*MaskedEmail, error) {
+	if filter == nil {
+		filter = &MaskedEmailFilter{}
+	}
+
+	label := ""
+	if filter.Label != "" {
+		var err error
+		if label, err = normalizeLabel(filter.Label); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.withLabels(telegramID, maskedEmails); err != nil {
+		return nil, err
+	}
+
+	result := make([]*MaskedEmail, 0, len(maskedEmails))
+	for _, maskedEmail := range maskedEmails {
+		if len(filter.States) == 0 && maskedEmail.State == MaskedEmailStateDeleted {
+			continue
+		}
+
+		if len(filter.States) > 0 && !slices.Contains(filter.States, maskedEmail.State) {
+			continue
+		}
+
+		if label != "" && !slices.Contains(maskedEmail.Labels, label) {
+			continue
+		}
+
+		result = append(result, maskedEmail)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Email < result[j].Email
+	})
+
+	return result, nil
+}
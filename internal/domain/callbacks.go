@@ -0,0 +1,8 @@
+package domain
+
+import "time"
+
+// PurgeCallbacks forgets buttons nobody is able to press anymore.
+func (s *service) PurgeCallbacks() error {
+	return s.db.DeleteCallbacksExpiredBefore(time.Now().UTC())
+}
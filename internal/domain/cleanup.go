@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var stateEvents = map[MaskedEmailState]MaskedEmailEventType{
+	MaskedEmailStateEnabled:  MaskedEmailEventEnabled,
+	MaskedEmailStateDisabled: MaskedEmailEventDisabled,
+	MaskedEmailStateDeleted:  MaskedEmailEventDeleted,
+}
+
+var stateTopicMessageIDs = map[MaskedEmailState]string{
+	MaskedEmailStateEnabled:  "TelegramTopicEmailEnabled",
+	MaskedEmailStateDisabled: "TelegramTopicEmailDisabled",
+	MaskedEmailStateDeleted:  "TelegramTopicEmailDeleted",
+}
+
+func (s *service) StaleMaskedEmails(telegramID int64, months int) (*StaleMaskedEmails, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(maskedEmails, func(i, j int) bool {
+		return maskedEmails[i].CreatedAt.Before(maskedEmails[j].CreatedAt)
+	})
+
+	cutoff := time.Now().AddDate(0, -months, 0)
+	stale := &StaleMaskedEmails{}
+	for _, maskedEmail := range maskedEmails {
+		switch {
+		case maskedEmail.State == MaskedEmailStateDeleted:
+		case maskedEmail.State == MaskedEmailStatePending:
+			stale.Pending = append(stale.Pending, maskedEmail)
+		case maskedEmail.LastMessageAt.IsZero() && maskedEmail.CreatedAt.Before(cutoff):
+			stale.NeverUsed = append(stale.NeverUsed, maskedEmail)
+		case !maskedEmail.LastMessageAt.IsZero() && maskedEmail.LastMessageAt.Before(cutoff):
+			stale.Silent = append(stale.Silent, maskedEmail)
+		}
+	}
+
+	return stale, nil
+}
+
+func (s *service) SetMaskedEmailsState(telegramID int64, ids []string, state MaskedEmailState) (*BatchResult, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.setMaskedEmailsState(ctx, tokenSrc, telegramID, maskedEmails, ids, state)
+}
+
+// setMaskedEmailsState changes states of the given masked emails, maskedEmails supply their prior states.
+func (s *service) setMaskedEmailsState(
+	ctx context.Context,
+	tokenSrc oauth2.TokenSource,
+	telegramID int64,
+	maskedEmails []*MaskedEmail,
+	ids []string,
+	state MaskedEmailState,
+) (*BatchResult, error) {
+	byID := make(map[string]*MaskedEmail, len(maskedEmails))
+	for _, maskedEmail := range maskedEmails {
+		byID[maskedEmail.ID] = maskedEmail
+	}
+
+	updates := make(map[string]*MaskedEmailUpdate, len(ids))
+	priors := make(map[string]*MaskedEmail, len(ids))
+	for _, id := range ids {
+		updates[id] = &MaskedEmailUpdate{State: state}
+		if maskedEmail, ok := byID[id]; ok {
+			priors[id] = maskedEmail
+		}
+	}
+
+	result, err := s.updateMaskedEmails(ctx, tokenSrc, telegramID, updates, priors)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range result.Succeeded {
+		s.recordEvent(telegramID, id, stateEvents[state], "")
+		s.notifyForumTopic(telegramID, id, stateTopicMessageIDs[state])
+	}
+
+	return result, nil
+}
@@ -0,0 +1,7 @@
+package domain
+
+import "time"
+
+type Config struct {
+	UndoWindow time.Duration `env:"UNDO_WINDOW,default=5m"`
+}
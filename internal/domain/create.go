@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// maxPrefixLength is the longest email prefix Fastmail accepts.
+const maxPrefixLength = 64
+
+func (s *service) CreateMaskedEmail(telegramID int64, request *MaskedEmailRequest) (*MaskedEmail, error) {
+	return s.requestMaskedEmail(telegramID, request, false)
+}
+
+func (s *service) CreateMaskedEmailForBlockedDomain(telegramID int64, request *MaskedEmailRequest) (*MaskedEmail, error) {
+	return s.requestMaskedEmail(telegramID, request, true)
+}
+
+// requestMaskedEmail validates the options before creating anything, so a typo doesn't leave a wrong address behind.
+func (s *service) requestMaskedEmail(telegramID int64, request *MaskedEmailRequest, allowBlocked bool) (*MaskedEmail, error) {
+	create := &MaskedEmailCreate{
+		State:       request.State,
+		Description: strings.TrimSpace(request.Description),
+	}
+
+	if request.ForDomain != "" {
+		target := ParseMaskedEmailTarget(request.ForDomain)
+		if target.URL == nil {
+			return nil, ErrInvalidDomain
+		}
+		create.ForDomain, create.EmailPrefix = target.ForDomain(), target.Prefix
+	}
+
+	// An explicit prefix wins over the one made of the site
+	if request.Prefix != "" {
+		prefix := strings.ToLower(request.Prefix)
+		if len(prefix) > maxPrefixLength || !prefixPattern.MatchString(prefix) {
+			return nil, ErrInvalidPrefix
+		}
+		create.EmailPrefix = prefix
+	}
+
+	labels := make([]string, 0, len(request.Labels))
+	for _, label := range request.Labels {
+		label, err := normalizeLabel(label)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+
+	return s.createMaskedEmail(telegramID, create, labels, allowBlocked, "")
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// recordEvent journals a change made through the bot, failing to do so must not fail the change itself.
+func (s *service) recordEvent(telegramID int64, maskedEmailID string, eventType MaskedEmailEventType, details string) {
+	if err := s.db.SaveMaskedEmailEvent(telegramID, &MaskedEmailEvent{
+		MaskedEmailID: maskedEmailID,
+		Type:          eventType,
+		Details:       details,
+		CreatedAt:     time.Now().UTC(),
+	}); err != nil {
+		s.logger.Error("Error while recording a masked email event!", zap.Error(err))
+	}
+}
+
+func (s *service) maskedEmailDetails(telegramID int64, maskedEmail *MaskedEmail) (*MaskedEmailDetails, error) {
+	if err := s.withLabels(telegramID, []*MaskedEmail{maskedEmail}); err != nil {
+		return nil, err
+	}
+
+	history, err := s.db.GetMaskedEmailEvents(telegramID, maskedEmail.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MaskedEmailDetails{
+		MaskedEmail: maskedEmail,
+		History:     history,
+	}, nil
+}
+
+func (s *service) MaskedEmailDetails(telegramID int64, id string) (*MaskedEmailDetails, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmail, err := s.email.GetMaskedEmail(ctx, tokenSrc, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.maskedEmailDetails(telegramID, maskedEmail)
+}
+
+func (s *service) MaskedEmailDetailsByAddress(telegramID int64, email string) (*MaskedEmailDetails, error) {
+	address, err := parseAddress(email)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	// MaskedEmail/get can't filter by address, so look through all of them
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, maskedEmail := range maskedEmails {
+		if strings.EqualFold(maskedEmail.Email, address) {
+			return s.maskedEmailDetails(telegramID, maskedEmail)
+		}
+	}
+
+	return nil, ErrNoMaskedEmail
+}
@@ -0,0 +1,289 @@
+package domain
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// digestMailsLimit caps how many mails of the period are inspected for noisy senders and leaks.
+	digestMailsLimit = 500
+	// digestFirstMailChecks caps how many addresses are checked for their first mail.
+	digestFirstMailChecks = 20
+	digestNoisySenders    = 5
+)
+
+// PendingLifetime is how long Fastmail keeps a pending masked email that hasn't received any mail.
+const PendingLifetime = 24 * time.Hour
+
+// baseDomain strips subdomains down to the registrable domain, so mail.shop.example.co.uk and example.co.uk compare equal.
+func baseDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if net.ParseIP(host) != nil {
+		return host
+	}
+
+	base, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		// Public suffixes have nothing to strip
+		return host
+	}
+
+	return base
+}
+
+// isPublicSuffix reports whether anyone may register domains under the host, like com or co.uk.
+func isPublicSuffix(host string) bool {
+	suffix, _ := publicsuffix.PublicSuffix(host)
+	return suffix == host
+}
+
+// forDomainHost extracts the host from forDomain, which is usually an origin like https://example.com.
+func forDomainHost(forDomain string) string {
+	if u, err := url.Parse(forDomain); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+
+	return forDomain
+}
+
+func digestPeriod(schedule DigestSchedule) time.Duration {
+	if schedule == DigestScheduleWeekly {
+		return 7 * 24 * time.Hour
+	}
+
+	return 24 * time.Hour
+}
+
+// digestDueAt returns the latest scheduled digest time that isn't after now.
+func digestDueAt(settings DigestSettings, now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	due := time.Date(local.Year(), local.Month(), local.Day(), settings.Hour, 0, 0, 0, loc)
+
+	if settings.Schedule == DigestScheduleWeekly {
+		// Weekly digests go out on Mondays
+		due = due.AddDate(0, 0, -((int(due.Weekday()) + 6) % 7))
+		if due.After(local) {
+			due = due.AddDate(0, 0, -7)
+		}
+		return due
+	}
+
+	if due.After(local) {
+		due = due.AddDate(0, 0, -1)
+	}
+	return due
+}
+
+func loadLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+func (s *service) buildDigest(ctx context.Context, tokenSrc oauth2.TokenSource, schedule DigestSchedule, loc *time.Location, now time.Time) (*Digest, error) {
+	digest := &Digest{
+		Schedule: schedule,
+		Location: loc,
+		From:     now.Add(-digestPeriod(schedule)),
+		To:       now,
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmail := make(map[string]*MaskedEmail, len(maskedEmails))
+	received := make([]*MaskedEmail, 0)
+	for _, maskedEmail := range maskedEmails {
+		byEmail[strings.ToLower(maskedEmail.Email)] = maskedEmail
+
+		if maskedEmail.CreatedAt.After(digest.From) {
+			digest.Created = append(digest.Created, maskedEmail)
+		}
+
+		if maskedEmail.State == MaskedEmailStatePending && now.Sub(maskedEmail.CreatedAt) < PendingLifetime {
+			digest.Expiring = append(digest.Expiring, maskedEmail)
+		}
+
+		if maskedEmail.LastMessageAt.After(digest.From) {
+			received = append(received, maskedEmail)
+		}
+	}
+
+	sort.Slice(digest.Created, func(i, j int) bool {
+		return digest.Created[i].CreatedAt.Before(digest.Created[j].CreatedAt)
+	})
+	sort.Slice(digest.Expiring, func(i, j int) bool {
+		return digest.Expiring[i].CreatedAt.Before(digest.Expiring[j].CreatedAt)
+	})
+
+	for i, maskedEmail := range received {
+		if i == digestFirstMailChecks {
+			break
+		}
+
+		// Anything received by an address created within the period is its first mail
+		if !maskedEmail.CreatedAt.After(digest.From) {
+			count, err := s.email.CountMails(ctx, tokenSrc, &MailFilter{To: maskedEmail.Email, Before: digest.From})
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				continue
+			}
+		}
+
+		digest.FirstMail = append(digest.FirstMail, maskedEmail)
+	}
+
+	mails, err := s.email.GetMails(ctx, tokenSrc, &MailFilter{After: digest.From}, digestMailsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	senders := make(map[string]int)
+	leaks := make(map[Leak]struct{})
+	for _, mail := range mails {
+		for _, to := range mail.To {
+			maskedEmail, ok := byEmail[strings.ToLower(to)]
+			if !ok {
+				continue
+			}
+
+			senders[mail.From]++
+
+			_, senderDomain, _ := strings.Cut(mail.From, "@")
+			if maskedEmail.ForDomain != "" && baseDomain(senderDomain) != baseDomain(forDomainHost(maskedEmail.ForDomain)) {
+				leak := Leak{
+					Email:     maskedEmail.Email,
+					ForDomain: maskedEmail.ForDomain,
+					Sender:    mail.From,
+				}
+				if _, ok := leaks[leak]; !ok {
+					leaks[leak] = struct{}{}
+					digest.Leaks = append(digest.Leaks, &leak)
+				}
+			}
+			break
+		}
+	}
+
+	for sender, count := range senders {
+		digest.NoisySenders = append(digest.NoisySenders, &SenderCount{Sender: sender, Count: count})
+	}
+	sort.Slice(digest.NoisySenders, func(i, j int) bool {
+		if digest.NoisySenders[i].Count != digest.NoisySenders[j].Count {
+			return digest.NoisySenders[i].Count > digest.NoisySenders[j].Count
+		}
+		return digest.NoisySenders[i].Sender < digest.NoisySenders[j].Sender
+	})
+	if len(digest.NoisySenders) > digestNoisySenders {
+		digest.NoisySenders = digest.NoisySenders[:digestNoisySenders]
+	}
+
+	return digest, nil
+}
+
+func (s *service) DigestSettings(telegramID int64) (*DigestSettings, error) {
+	user, err := s.db.GetUser(telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.Digest, nil
+}
+
+func (s *service) UpdateDigestSettings(telegramID int64, settings *DigestSettings) error {
+	switch settings.Schedule {
+	case DigestScheduleOff, DigestScheduleDaily, DigestScheduleWeekly:
+	default:
+		return ErrInvalidDigestSettings
+	}
+
+	if settings.Hour < 0 || settings.Hour > 23 {
+		return ErrInvalidDigestSettings
+	}
+
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return ErrInvalidDigestSettings
+	}
+
+	if err := s.db.UpdateDigestSettings(telegramID, settings); err != nil {
+		return err
+	}
+
+	// Start counting from now, so the first digest doesn't go out immediately
+	return s.db.UpdateDigestSentAt(telegramID, time.Now().UTC())
+}
+
+func (s *service) sendDigest(ctx context.Context, user *User, now time.Time) error {
+	tokenSrc, err := s.tokenSource(ctx, user.TelegramID)
+	if err != nil {
+		return err
+	}
+
+	schedule := user.Digest.Schedule
+	if schedule == DigestScheduleOff {
+		schedule = DigestScheduleDaily
+	}
+
+	digest, err := s.buildDigest(ctx, tokenSrc, schedule, loadLocation(user.Digest.Timezone), now)
+	if err != nil {
+		return err
+	}
+
+	return s.telegram.SendDigest(user.TelegramID, user.LanguageCode, digest)
+}
+
+func (s *service) SendDigest(telegramID int64) error {
+	user, err := s.db.GetUser(telegramID)
+	if err != nil {
+		return err
+	}
+
+	return s.sendDigest(context.Background(), user, time.Now().UTC())
+}
+
+func (s *service) SendDigests() error {
+	users, err := s.db.GetDigestUsers()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, user := range users {
+		due := digestDueAt(user.Digest, now, loadLocation(user.Digest.Timezone))
+		if !user.DigestSentAt.Before(due) {
+			continue
+		}
+
+		if err := s.sendDigest(ctx, user, now); err != nil {
+			s.logger.Error(
+				"Error while sending a digest!",
+				zap.Int64("telegram_id", user.TelegramID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if err := s.db.UpdateDigestSentAt(user.TelegramID, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
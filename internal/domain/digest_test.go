@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDigestDueAt(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name     string
+		settings DigestSettings
+		now      time.Time
+		loc      *time.Location
+		want     time.Time
+	}{
+		{
+			"daily after the hour",
+			DigestSettings{Schedule: DigestScheduleDaily, Hour: 9},
+			time.Date(2026, 10, 21, 12, 0, 0, 0, time.UTC),
+			time.UTC,
+			time.Date(2026, 10, 21, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			"daily at the hour",
+			DigestSettings{Schedule: DigestScheduleDaily, Hour: 9},
+			time.Date(2026, 10, 21, 9, 0, 0, 0, time.UTC),
+			time.UTC,
+			time.Date(2026, 10, 21, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			"daily before the hour",
+			DigestSettings{Schedule: DigestScheduleDaily, Hour: 9},
+			time.Date(2026, 10, 21, 8, 59, 0, 0, time.UTC),
+			time.UTC,
+			time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			"daily in the user's timezone",
+			DigestSettings{Schedule: DigestScheduleDaily, Hour: 2},
+			time.Date(2026, 10, 20, 23, 30, 0, 0, time.UTC),
+			moscow,
+			time.Date(2026, 10, 21, 2, 0, 0, 0, moscow),
+		},
+		{
+			"weekly midweek",
+			DigestSettings{Schedule: DigestScheduleWeekly, Hour: 9},
+			time.Date(2026, 10, 21, 12, 0, 0, 0, time.UTC),
+			time.UTC,
+			time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			"weekly on Sunday",
+			DigestSettings{Schedule: DigestScheduleWeekly, Hour: 9},
+			time.Date(2026, 10, 25, 23, 0, 0, 0, time.UTC),
+			time.UTC,
+			time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			"weekly on Monday before the hour",
+			DigestSettings{Schedule: DigestScheduleWeekly, Hour: 9},
+			time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC),
+			time.UTC,
+			time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			"weekly on Monday after the hour",
+			DigestSettings{Schedule: DigestScheduleWeekly, Hour: 9},
+			time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+			time.UTC,
+			time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := digestDueAt(tt.settings, tt.now, tt.loc); !got.Equal(tt.want) {
+				t.Errorf("digestDueAt(%+v, %s) = %s, want %s", tt.settings, tt.now, got, tt.want)
+			}
+		})
+	}
+}
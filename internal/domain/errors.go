@@ -6,12 +6,36 @@ var (
 	ErrNoUser                         = errors.New("common: no user")
 	ErrNoToken                        = errors.New("common: no token")
 	ErrNoState                        = errors.New("common: no state")
+	ErrNoMail                         = errors.New("common: no mail")
+	ErrNoSelection                    = errors.New("common: no selection")
+	ErrNoMaskedEmail                  = errors.New("common: no masked email")
+	ErrNoMaskedEmailMessage           = errors.New("common: no masked email message")
+	ErrNoConversation                 = errors.New("common: no conversation")
+	ErrConversationExpired            = errors.New("common: conversation expired")
+	ErrNoCallback                     = errors.New("common: no callback")
+	ErrNoUndo                         = errors.New("common: no undo")
+	ErrUndoExpired                    = errors.New("common: undo window has passed")
+	ErrInvalidAddress                 = errors.New("common: invalid email address")
+	ErrNoUnsubscribe                  = errors.New("common: no unsubscribe method")
+	ErrNoForumTopic                   = errors.New("common: no forum topic")
+	ErrNotForum                       = errors.New("common: chat is not a forum")
+	ErrInvalidDigestSettings          = errors.New("common: invalid digest settings")
+	ErrInvalidDomain                  = errors.New("common: invalid domain")
+	ErrInvalidPrefix                  = errors.New("common: invalid email prefix")
+	ErrInvalidLabel                   = errors.New("common: invalid label")
+	ErrInvalidExportFormat            = errors.New("common: invalid export format")
+	ErrInvalidImport                  = errors.New("common: no services found in the import file")
+	ErrDomainBlocked                  = errors.New("common: domain is blocked")
+	ErrRotationIncomplete             = errors.New("common: replaced masked email is still active")
 	ErrRandom                         = errors.New("common: cannot generate random bytes")
 	ErrJSONEncoding                   = errors.New("common: cannot encode json")
 	ErrFastmailInternal               = errors.New("fastmail: internal error")
 	ErrFastmailPrimaryAccountNotFound = errors.New("fastmail: primary account not found")
+	ErrFastmailInsufficientScope      = errors.New("fastmail: token lacks the scope")
 	ErrTelegramInternal               = errors.New("telegram: internal error")
 	ErrHTTPInternal                   = errors.New("http: internal error")
+	ErrUnsubscribeInternal            = errors.New("unsubscribe: internal error")
+	ErrBreachesInternal               = errors.New("breaches: internal error")
 	ErrSqliteInternal                 = errors.New("sqlite: internal error")
 	ErrSqliteUserAlreadyExists        = errors.New("sqlite: user already exists")
 )
@@ -0,0 +1,352 @@
+package domain
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV       ExportFormat = "csv"
+	ExportFormatJSON      ExportFormat = "json"
+	ExportFormatBitwarden ExportFormat = "bitwarden"
+	ExportFormat1Password ExportFormat = "1password"
+	ExportFormatKeePass   ExportFormat = "keepass"
+)
+
+// ExportFormats lists supported formats in the order they are offered.
+var ExportFormats = []ExportFormat{
+	ExportFormatCSV,
+	ExportFormatJSON,
+	ExportFormatBitwarden,
+	ExportFormat1Password,
+	ExportFormatKeePass,
+}
+
+var exportFilenames = map[ExportFormat]string{
+	ExportFormatCSV:       "masked-emails.csv",
+	ExportFormatJSON:      "masked-emails.json",
+	ExportFormatBitwarden: "masked-emails-bitwarden.json",
+	ExportFormat1Password: "masked-emails-1password.csv",
+	ExportFormatKeePass:   "masked-emails-keepass.xml",
+}
+
+// Export is a set of masked emails ready to be written in the chosen format.
+type Export struct {
+	Format       ExportFormat
+	MaskedEmails []*MaskedEmail
+}
+
+func (e *Export) Filename() string {
+	return exportFilenames[e.Format]
+}
+
+// WriteTo encodes masked emails one by one, so the output can be streamed as it's produced.
+func (e *Export) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+
+	var err error
+	switch e.Format {
+	case ExportFormatCSV:
+		err = writeCSV(cw, e.MaskedEmails)
+	case ExportFormatJSON:
+		err = writeJSON(cw, e.MaskedEmails)
+	case ExportFormatBitwarden:
+		err = writeBitwarden(cw, e.MaskedEmails)
+	case ExportFormat1Password:
+		err = write1Password(cw, e.MaskedEmails)
+	case ExportFormatKeePass:
+		err = writeKeePass(cw, e.MaskedEmails)
+	default:
+		err = ErrInvalidExportFormat
+	}
+
+	return cw.n, err
+}
+
+func (s *service) ExportMaskedEmails(telegramID int64, format ExportFormat, filter *MaskedEmailFilter) (*Export, error) {
+	if _, ok := exportFilenames[format]; !ok {
+		return nil, ErrInvalidExportFormat
+	}
+
+	maskedEmails, err := s.MaskedEmails(telegramID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Export{
+		Format:       format,
+		MaskedEmails: maskedEmails,
+	}, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// exportTitle names an entry after its service, addresses without one fall back to description and then the address.
+func exportTitle(maskedEmail *MaskedEmail) string {
+	if maskedEmail.ForDomain != "" {
+		return strings.TrimPrefix(forDomainHost(maskedEmail.ForDomain), "www.")
+	}
+
+	if maskedEmail.Description != "" {
+		return maskedEmail.Description
+	}
+
+	return maskedEmail.Email
+}
+
+func writeCSV(w io.Writer, maskedEmails []*MaskedEmail) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{
+		"email", "state", "for_domain", "description", "labels", "created_at", "last_message_at", "created_by", "id",
+	}); err != nil {
+		return err
+	}
+
+	for _, maskedEmail := range maskedEmails {
+		if err := cw.Write([]string{
+			maskedEmail.Email,
+			string(maskedEmail.State),
+			maskedEmail.ForDomain,
+			maskedEmail.Description,
+			strings.Join(maskedEmail.Labels, " "),
+			formatExportTime(maskedEmail.CreatedAt),
+			formatExportTime(maskedEmail.LastMessageAt),
+			maskedEmail.CreatedBy,
+			maskedEmail.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type exportedMaskedEmail struct {
+	ID            string           `json:"id"`
+	Email         string           `json:"email"`
+	State         MaskedEmailState `json:"state"`
+	ForDomain     string           `json:"forDomain,omitempty"`
+	Description   string           `json:"description,omitempty"`
+	URL           string           `json:"url,omitempty"`
+	EmailPrefix   string           `json:"emailPrefix,omitempty"`
+	CreatedBy     string           `json:"createdBy,omitempty"`
+	CreatedAt     string           `json:"createdAt,omitempty"`
+	LastMessageAt string           `json:"lastMessageAt,omitempty"`
+	Labels        []string         `json:"labels"`
+}
+
+// writeJSONArray writes elements of a JSON array one at a time instead of marshalling the whole list.
+func writeJSONArray(w io.Writer, n int, element func(i int) any) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+
+		b, err := json.Marshal(element(i))
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+func writeJSON(w io.Writer, maskedEmails []*MaskedEmail) error {
+	return writeJSONArray(w, len(maskedEmails), func(i int) any {
+		maskedEmail := maskedEmails[i]
+
+		labels := maskedEmail.Labels
+		if labels == nil {
+			labels = []string{}
+		}
+
+		return &exportedMaskedEmail{
+			ID:            maskedEmail.ID,
+			Email:         maskedEmail.Email,
+			State:         maskedEmail.State,
+			ForDomain:     maskedEmail.ForDomain,
+			Description:   maskedEmail.Description,
+			URL:           maskedEmail.URL,
+			EmailPrefix:   maskedEmail.EmailPrefix,
+			CreatedBy:     maskedEmail.CreatedBy,
+			CreatedAt:     formatExportTime(maskedEmail.CreatedAt),
+			LastMessageAt: formatExportTime(maskedEmail.LastMessageAt),
+			Labels:        labels,
+		}
+	})
+}
+
+type bitwardenURI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+type bitwardenLogin struct {
+	Username string          `json:"username"`
+	Password *string         `json:"password"`
+	URIs     []*bitwardenURI `json:"uris"`
+}
+
+type bitwardenField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  int    `json:"type"`
+}
+
+type bitwardenItem struct {
+	Type     int               `json:"type"`
+	Name     string            `json:"name"`
+	Notes    *string           `json:"notes"`
+	Favorite bool              `json:"favorite"`
+	Login    *bitwardenLogin   `json:"login"`
+	Fields   []*bitwardenField `json:"fields"`
+}
+
+// bitwardenTypeLogin and bitwardenFieldText are item and custom field types of the Bitwarden export format.
+const (
+	bitwardenTypeLogin = 1
+	bitwardenFieldText = 0
+)
+
+// writeBitwarden writes an unencrypted Bitwarden export with a login per masked email.
+func writeBitwarden(w io.Writer, maskedEmails []*MaskedEmail) error {
+	if _, err := io.WriteString(w, `{"encrypted":false,"folders":[],"items":`); err != nil {
+		return err
+	}
+
+	if err := writeJSONArray(w, len(maskedEmails), func(i int) any {
+		maskedEmail := maskedEmails[i]
+
+		item := &bitwardenItem{
+			Type: bitwardenTypeLogin,
+			Name: exportTitle(maskedEmail),
+			Login: &bitwardenLogin{
+				Username: maskedEmail.Email,
+				URIs:     make([]*bitwardenURI, 0, 1),
+			},
+			Fields: []*bitwardenField{
+				{Name: "state", Value: string(maskedEmail.State), Type: bitwardenFieldText},
+			},
+		}
+		if maskedEmail.Description != "" {
+			item.Notes = &maskedEmail.Description
+		}
+		if maskedEmail.ForDomain != "" {
+			item.Login.URIs = append(item.Login.URIs, &bitwardenURI{URI: maskedEmail.ForDomain})
+		}
+		if len(maskedEmail.Labels) > 0 {
+			item.Fields = append(item.Fields, &bitwardenField{
+				Name:  "labels",
+				Value: strings.Join(maskedEmail.Labels, " "),
+				Type:  bitwardenFieldText,
+			})
+		}
+
+		return item
+	}); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "}")
+	return err
+}
+
+// write1Password writes the CSV layout 1Password imports logins from.
+func write1Password(w io.Writer, maskedEmails []*MaskedEmail) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Title", "Website", "Username", "Password", "Notes", "Tags"}); err != nil {
+		return err
+	}
+
+	for _, maskedEmail := range maskedEmails {
+		if err := cw.Write([]string{
+			exportTitle(maskedEmail),
+			maskedEmail.ForDomain,
+			maskedEmail.Email,
+			"",
+			maskedEmail.Description,
+			strings.Join(maskedEmail.Labels, ","),
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type keePassString struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+type keePassEntry struct {
+	XMLName xml.Name         `xml:"Entry"`
+	Strings []*keePassString `xml:"String"`
+	Tags    string           `xml:"Tags,omitempty"`
+}
+
+// writeKeePass writes a KeePass 2 XML file with a single group holding an entry per masked email.
+func writeKeePass(w io.Writer, maskedEmails []*MaskedEmail) error {
+	if _, err := io.WriteString(w, xml.Header+"<KeePassFile><Root><Group><Name>Masked emails</Name>"); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	for _, maskedEmail := range maskedEmails {
+		if err := enc.Encode(&keePassEntry{
+			Strings: []*keePassString{
+				{Key: "Title", Value: exportTitle(maskedEmail)},
+				{Key: "UserName", Value: maskedEmail.Email},
+				{Key: "Password", Value: ""},
+				{Key: "URL", Value: maskedEmail.ForDomain},
+				{Key: "Notes", Value: maskedEmail.Description},
+			},
+			Tags: strings.Join(maskedEmail.Labels, ";"),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "</Group></Root></KeePassFile>")
+	return err
+}
@@ -0,0 +1,225 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// forumMailsLimit is how many new mails are fetched at once.
+	forumMailsLimit = 50
+	// forumPollPages caps how many pages of new mails are previewed per user on every poll, the rest waits for the next one.
+	forumPollPages = 10
+)
+
+func (s *service) ConnectForum(telegramID, chatID int64) error {
+	if _, err := s.db.GetUser(telegramID); err != nil {
+		return err
+	}
+
+	isForum, err := s.telegram.IsForum(chatID)
+	if err != nil {
+		return err
+	}
+
+	if !isForum {
+		return ErrNotForum
+	}
+
+	return s.db.UpdateForumChat(telegramID, chatID)
+}
+
+func (s *service) DisconnectForum(telegramID int64) error {
+	return s.db.UpdateForumChat(telegramID, 0)
+}
+
+// createForumTopic opens a topic for a freshly created masked email when the user has a forum connected.
+func (s *service) createForumTopic(telegramID int64, maskedEmail *MaskedEmail) {
+	user, err := s.db.GetUser(telegramID)
+	if err != nil {
+		s.logger.Error("Error while getting a user for a forum topic!", zap.Error(err))
+		return
+	}
+
+	if user.ForumChatID == 0 {
+		return
+	}
+
+	threadID, err := s.telegram.CreateTopic(user.ForumChatID, maskedEmail.Email)
+	if err != nil {
+		// Usually the bot has lost the right to manage topics
+		s.logger.Error(
+			"Error while creating a forum topic!",
+			zap.Int64("chat_id", user.ForumChatID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if err := s.db.CreateForumTopic(&ForumTopic{
+		TelegramID:    telegramID,
+		MaskedEmailID: maskedEmail.ID,
+		Email:         maskedEmail.Email,
+		ChatID:        user.ForumChatID,
+		ThreadID:      threadID,
+	}); err != nil {
+		s.logger.Error("Error while saving a forum topic!", zap.Error(err))
+		return
+	}
+
+	if err := s.telegram.SendTopicMessage(
+		user.ForumChatID,
+		threadID,
+		user.LanguageCode,
+		"TelegramTopicEmailCreated",
+		map[string]interface{}{"Email": maskedEmail.Email},
+	); err != nil {
+		s.logger.Error("Error while announcing a masked email!", zap.Error(err))
+	}
+}
+
+// notifyForumTopic posts a lifecycle event into the topic of the masked email, if there is one.
+func (s *service) notifyForumTopic(telegramID int64, maskedEmailID, messageID string) {
+	topic, err := s.db.GetForumTopic(telegramID, maskedEmailID)
+	if err != nil {
+		return
+	}
+
+	user, err := s.db.GetUser(telegramID)
+	if err != nil || user.ForumChatID != topic.ChatID {
+		return
+	}
+
+	if err := s.telegram.SendTopicMessage(
+		topic.ChatID,
+		topic.ThreadID,
+		user.LanguageCode,
+		messageID,
+		map[string]interface{}{"Email": topic.Email},
+	); err != nil {
+		s.logger.Error("Error while posting to a forum topic!", zap.Error(err))
+	}
+}
+
+func (s *service) pollForumMails(ctx context.Context, user *User) error {
+	// Nothing to catch up with on the first poll after connecting the forum
+	if user.ForumPolledAt.IsZero() {
+		return s.db.UpdateForumPolledAt(user.TelegramID, time.Now().UTC(), nil)
+	}
+
+	tokenSrc, err := s.tokenSource(ctx, user.TelegramID)
+	if err != nil {
+		return err
+	}
+
+	// The cursor moves only past mails that have been handled, so a burst is caught up with page by page
+	// and previews that failed are retried on the next poll without posting the previous ones again.
+	// The "after" filter includes the cursor itself, mails received in that second are told apart by their IDs.
+	polledAt := user.ForumPolledAt
+	polledIDs := make(map[string]bool, len(user.ForumPolledIDs))
+	for _, id := range user.ForumPolledIDs {
+		polledIDs[id] = true
+	}
+
+	save := func() error {
+		ids := make([]string, 0, len(polledIDs))
+		for id := range polledIDs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		return s.db.UpdateForumPolledAt(user.TelegramID, polledAt, ids)
+	}
+
+	for page := 0; page < forumPollPages; page++ {
+		// Mails posted at the cursor come first, the page is made longer to fit them
+		limit := forumMailsLimit + len(polledIDs)
+		mails, err := s.email.GetMails(ctx, tokenSrc, &MailFilter{After: polledAt, OldestFirst: true}, limit)
+		if err != nil {
+			return err
+		}
+
+		sort.SliceStable(mails, func(i, j int) bool {
+			return mails[i].ReceivedAt.Before(mails[j].ReceivedAt)
+		})
+
+		handled := 0
+		for _, mail := range mails {
+			if mail.ReceivedAt.Before(polledAt) || (mail.ReceivedAt.Equal(polledAt) && polledIDs[mail.ID]) {
+				continue
+			}
+
+			if err := s.postForumMail(user, mail); err != nil {
+				if handled > 0 {
+					if err := save(); err != nil {
+						s.logger.Error("Error while saving the forum poll cursor!", zap.Error(err))
+					}
+				}
+				return err
+			}
+
+			if mail.ReceivedAt.After(polledAt) {
+				polledAt = mail.ReceivedAt
+				clear(polledIDs)
+			}
+			polledIDs[mail.ID] = true
+			handled++
+		}
+
+		if handled == 0 {
+			// Another query from an unchanged cursor would return the same page
+			break
+		}
+
+		if err := save(); err != nil {
+			return err
+		}
+
+		if len(mails) < limit {
+			break
+		}
+	}
+
+	return nil
+}
+
+// postForumMail previews the mail in the topic of the masked email it has been received by, if there is one.
+func (s *service) postForumMail(user *User, mail *Mail) error {
+	for _, to := range append(append([]string{}, mail.To...), mail.Cc...) {
+		topic, err := s.db.GetForumTopicByEmail(user.TelegramID, to)
+		if errors.Is(err, ErrNoForumTopic) || (err == nil && topic.ChatID != user.ForumChatID) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		return s.telegram.SendMailPreview(topic.ChatID, topic.ThreadID, user.LanguageCode, mail)
+	}
+
+	return nil
+}
+
+func (s *service) PollForumMails() error {
+	users, err := s.db.GetForumUsers()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, user := range users {
+		if err := s.pollForumMails(ctx, user); err != nil {
+			s.logger.Error(
+				"Error while polling mails for a forum!",
+				zap.Int64("telegram_id", user.TelegramID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
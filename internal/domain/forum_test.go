@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// forumDatabase keeps a single forum user with one topic.
+type forumDatabase struct {
+	Database
+	user  *User
+	topic *ForumTopic
+}
+
+func (db *forumDatabase) GetUser(int64) (*User, error) {
+	return db.user, nil
+}
+
+func (db *forumDatabase) NewTokenSource(baseTokenSource oauth2.TokenSource, _ int64) oauth2.TokenSource {
+	return baseTokenSource
+}
+
+func (db *forumDatabase) GetForumTopicByEmail(_ int64, email string) (*ForumTopic, error) {
+	if email != db.topic.Email {
+		return nil, ErrNoForumTopic
+	}
+	return db.topic, nil
+}
+
+func (db *forumDatabase) UpdateForumPolledAt(_ int64, polledAt time.Time, polledIDs []string) error {
+	db.user.ForumPolledAt = polledAt
+	db.user.ForumPolledIDs = polledIDs
+	return nil
+}
+
+// forumEmail answers mail queries the way JMAP does, "after" includes mails received at that time.
+type forumEmail struct {
+	MaskingEmail
+	mails []*Mail
+}
+
+func (e *forumEmail) GetOAuth2Config() *oauth2.Config {
+	return &oauth2.Config{}
+}
+
+func (e *forumEmail) GetMails(_ context.Context, _ oauth2.TokenSource, filter *MailFilter, limit int) ([]*Mail, error) {
+	mails := make([]*Mail, 0)
+	for _, mail := range e.mails {
+		if !mail.ReceivedAt.Before(filter.After) {
+			mails = append(mails, mail)
+		}
+	}
+
+	slices.SortStableFunc(mails, func(a, b *Mail) int {
+		return a.ReceivedAt.Compare(b.ReceivedAt)
+	})
+	return mails[:min(len(mails), limit)], nil
+}
+
+type forumTelegram struct {
+	Telegram
+	posted []string
+}
+
+func (t *forumTelegram) SendMailPreview(_ int64, _ int, _ string, mail *Mail) error {
+	t.posted = append(t.posted, mail.ID)
+	return nil
+}
+
+func TestPollForumMails(t *testing.T) {
+	cursor := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	mail := func(id string, receivedAt time.Time) *Mail {
+		return &Mail{ID: id, To: []string{"shop.123@fastmail.com"}, ReceivedAt: receivedAt}
+	}
+	burst := make([]*Mail, 0, forumMailsLimit+10)
+	burstIDs := make([]string, 0, forumMailsLimit+10)
+	for i := 0; i < forumMailsLimit+10; i++ {
+		id := fmt.Sprintf("burst-%02d", i)
+		burst = append(burst, mail(id, cursor.Add(time.Second)))
+		burstIDs = append(burstIDs, id)
+	}
+
+	tests := []struct {
+		name      string
+		polledIDs []string
+		mails     []*Mail
+		want      []string
+	}{
+		{
+			"boundary mail posted already",
+			[]string{"boundary"},
+			[]*Mail{mail("boundary", cursor)},
+			[]string{},
+		},
+		{
+			"another mail in the same second",
+			[]string{"boundary"},
+			[]*Mail{mail("boundary", cursor), mail("same-second", cursor), mail("later", cursor.Add(time.Minute))},
+			[]string{"same-second", "later"},
+		},
+		{
+			"more than a page in one second",
+			nil,
+			burst,
+			burstIDs,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &forumDatabase{
+				user: &User{
+					TelegramID:     1,
+					FastmailToken:  &oauth2.Token{AccessToken: "token"},
+					ForumChatID:    -100,
+					ForumPolledAt:  cursor,
+					ForumPolledIDs: tt.polledIDs,
+				},
+				topic: &ForumTopic{TelegramID: 1, Email: "shop.123@fastmail.com", ChatID: -100, ThreadID: 7},
+			}
+			tg := &forumTelegram{}
+			s := &service{
+				logger:   zap.NewNop(),
+				db:       db,
+				email:    &forumEmail{mails: tt.mails},
+				telegram: tg,
+			}
+
+			// The next polls find nothing new, so nothing is posted twice
+			for poll := 0; poll < 3; poll++ {
+				if err := s.pollForumMails(context.Background(), db.user); err != nil {
+					t.Fatalf("poll %d: %v", poll, err)
+				}
+			}
+
+			if !slices.Equal(tg.posted, tt.want) {
+				t.Errorf("posted %q, want %q", tg.posted, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// importFileLimit bounds how much of an uploaded file is read.
+	importFileLimit = 1 << 20
+	// importLimit bounds how many services one file can bring in.
+	importLimit = 200
+	// importBatchSize is how many masked emails are created per MaskedEmail/set call.
+	importBatchSize = 20
+)
+
+// importURLKeys are JSON keys and CSV columns that hold login URLs in password-manager exports.
+var importURLKeys = map[string]bool{
+	"uri":        true,
+	"url":        true,
+	"website":    true,
+	"login_uri":  true,
+	"fordomain":  true,
+	"for_domain": true,
+	"urls":       true,
+}
+
+// ImportCandidate is a service found in an uploaded file.
+type ImportCandidate struct {
+	URL  string
+	Host string
+	// Existing counts active masked emails the user already has for the service
+	Existing int
+	Blocked  bool
+}
+
+// ImportedMaskedEmail maps a service to the masked email created for it, MaskedEmail is nil when creation failed.
+type ImportedMaskedEmail struct {
+	URL         string
+	Host        string
+	MaskedEmail *MaskedEmail
+}
+
+// parseImportURLs finds login URLs in a Bitwarden or other JSON export, a CSV with a URL column, or a plain list.
+func parseImportURLs(data []byte) []*url.URL {
+	var values []string
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err == nil {
+		// A bare array of strings is a URL list too
+		values = jsonURLValues(doc, true)
+	} else if records, ok := csvURLValues(data); ok {
+		values = records
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Split(bufio.ScanWords)
+		for scanner.Scan() {
+			values = append(values, scanner.Text())
+		}
+	}
+
+	urls := make([]*url.URL, 0)
+	for _, value := range values {
+		if u, ok := siteURL(value); ok {
+			urls = append(urls, u)
+		}
+	}
+
+	return urls
+}
+
+// jsonURLValues collects strings stored under URL-like keys anywhere in the document.
+func jsonURLValues(v any, urlKey bool) []string {
+	values := make([]string, 0)
+	switch v := v.(type) {
+	case map[string]any:
+		for key, child := range v {
+			values = append(values, jsonURLValues(child, importURLKeys[strings.ToLower(key)])...)
+		}
+	case []any:
+		for _, child := range v {
+			values = append(values, jsonURLValues(child, urlKey)...)
+		}
+	case string:
+		if urlKey {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// csvURLValues reads the URL columns of a CSV with a header, it reports false when there are none.
+func csvURLValues(data []byte) ([]string, bool) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, false
+	}
+
+	columns := make([]int, 0)
+	for i, name := range header {
+		if importURLKeys[strings.ToLower(strings.TrimSpace(name))] {
+			columns = append(columns, i)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, false
+	}
+
+	values := make([]string, 0)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+
+		for _, i := range columns {
+			if i < len(record) {
+				// 1Password keeps several URLs of a login in one cell
+				values = append(values, strings.Fields(strings.ReplaceAll(record[i], ",", " "))...)
+			}
+		}
+	}
+
+	return values, true
+}
+
+func importHost(u *url.URL) string {
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+func (s *service) ImportCandidates(telegramID int64, r io.Reader) ([]*ImportCandidate, error) {
+	data, err := io.ReadAll(io.LimitReader(r, importFileLimit))
+	if err != nil {
+		s.logger.Error("Error while reading an import file!", zap.Error(err))
+		return nil, ErrInvalidImport
+	}
+
+	candidates := make([]*ImportCandidate, 0)
+	seen := make(map[string]bool)
+	for _, u := range parseImportURLs(data) {
+		host := importHost(u)
+		if seen[host] {
+			continue
+		}
+		seen[host] = true
+
+		candidates = append(candidates, &ImportCandidate{
+			URL:  u.String(),
+			Host: host,
+		})
+		if len(candidates) == importLimit {
+			break
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrInvalidImport
+	}
+
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]int)
+	for _, maskedEmail := range maskedEmails {
+		if maskedEmail.IsActive() && maskedEmail.ForDomain != "" {
+			existing[serviceDomain(maskedEmail.ForDomain)]++
+		}
+	}
+
+	blockedDomains, err := s.db.GetBlockedDomains(telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make(map[string]bool, len(blockedDomains))
+	for _, blockedDomain := range blockedDomains {
+		blocked[blockedDomain.Domain] = true
+	}
+
+	for _, candidate := range candidates {
+		domain := baseDomain(candidate.Host)
+		candidate.Existing = existing[domain]
+		candidate.Blocked = blocked[domain]
+	}
+
+	return candidates, nil
+}
+
+func (s *service) ImportMaskedEmails(telegramID int64, urls []string) ([]*ImportedMaskedEmail, error) {
+	parsed := make([]*url.URL, 0, len(urls))
+	result := make([]*ImportedMaskedEmail, 0, len(urls))
+	for _, rawURL := range urls {
+		u, ok := siteURL(rawURL)
+		if !ok {
+			continue
+		}
+
+		parsed = append(parsed, u)
+		result = append(result, &ImportedMaskedEmail{
+			URL:  u.String(),
+			Host: importHost(u),
+		})
+	}
+
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(parsed); start += importBatchSize {
+		end := min(start+importBatchSize, len(parsed))
+
+		// A failed batch is reported per service, the other batches still go through
+		maskedEmails, err := s.email.CreateMaskedEmailsFromURLs(ctx, tokenSrc, parsed[start:end])
+		if err != nil {
+			s.logger.Error(
+				"Error while importing a batch of masked emails!",
+				zap.Int64("telegram_id", telegramID),
+				zap.Int("from", start),
+				zap.Int("to", end),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		for i, maskedEmail := range maskedEmails {
+			if maskedEmail == nil {
+				continue
+			}
+
+			result[start+i].MaskedEmail = maskedEmail
+			s.recordEvent(telegramID, maskedEmail.ID, MaskedEmailEventCreated, "")
+			s.createForumTopic(telegramID, maskedEmail)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseImportURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"empty", "", []string{}},
+		{
+			"plain list",
+			"shop.com\nhttps://www.news.org/login?next=/\n  http://Forum.Example.com:8080/  \nnot-a-site",
+			[]string{"https://shop.com", "https://www.news.org", "http://forum.example.com:8080"},
+		},
+		{"other schemes", "ftp://files.com mailto:me@mail.com", []string{}},
+		{"json array", `["shop.com", "https://news.org/a"]`, []string{"https://shop.com", "https://news.org"}},
+		{
+			"bitwarden",
+			`{"items": [
+				{"name": "shop.com", "login": {"uris": [{"match": null, "uri": "https://shop.com/login"}]}},
+				{"name": "News", "notes": "news.org", "login": {"uris": [{"uri": "news.org"}]}},
+				{"name": "Card"}
+			]}`,
+			[]string{"https://shop.com", "https://news.org"},
+		},
+		{"json without url keys", `{"name": "shop.com", "notes": "news.org"}`, []string{}},
+		{
+			"csv",
+			"name,url,username\nShop,https://shop.com/login,me\nNews,\"news.org, https://mail.news.org\",me\n",
+			[]string{"https://shop.com", "https://news.org", "https://mail.news.org"},
+		},
+		{"csv without url column", "name,notes\nShop,shop.com\n", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, u := range parseImportURLs([]byte(tt.data)) {
+				got = append(got, u.String())
+			}
+
+			// Keys of a JSON object come in no particular order
+			sort.Strings(got)
+			want := append([]string{}, tt.want...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("parseImportURLs(%q) = %q, want %q", tt.data, got, want)
+			}
+		})
+	}
+}
+
+func TestCSVURLValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   []string
+		wantOK bool
+	}{
+		{"empty", "", nil, false},
+		{"no url column", "name,username\nShop,me\n", nil, false},
+		{"plain list", "shop.com\nnews.org\n", nil, false},
+		{"header only", "name,url\n", []string{}, true},
+		{"url column", "name,url\nShop,https://shop.com\nNews,news.org\n", []string{"https://shop.com", "news.org"}, true},
+		{"header case and spaces", "Name, Login_URI \nShop,shop.com\n", []string{"shop.com"}, true},
+		{"several columns", "url,website\nshop.com,news.org\n", []string{"shop.com", "news.org"}, true},
+		{"several urls in a cell", "name,urls\nShop,\"shop.com,https://m.shop.com shop.net\"\n", []string{"shop.com", "https://m.shop.com", "shop.net"}, true},
+		{"short record", "name,username,url\nShop\nNews,me,news.org\n", []string{"news.org"}, true},
+		{"empty cell", "name,url\nShop,\n", []string{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := csvURLValues([]byte(tt.data))
+			if ok != tt.wantOK {
+				t.Fatalf("csvURLValues(%q) ok = %t, want %t", tt.data, ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("csvURLValues(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
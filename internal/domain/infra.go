@@ -4,28 +4,110 @@ import (
 	"context"
 	"golang.org/x/oauth2"
 	"net/url"
+	"time"
 )
 
 type Database interface {
 	CreateUser(telegramID int64, languageCode string) error
 	UpdateToken(telegramID int64, fastmailToken string) error
 	UpdateLanguageCode(telegramID int64, languageCode string) error
+	ChooseLanguageCode(telegramID int64, languageCode string) error
 	GetUser(telegramID int64) (*User, error)
+	GetAuthorizedUsers() ([]*User, error)
+	GetForumUsers() ([]*User, error)
+	UpdateForumChat(telegramID, chatID int64) error
+	UpdateForumPolledAt(telegramID int64, polledAt time.Time, polledIDs []string) error
+	GetDigestUsers() ([]*User, error)
+	UpdateDigestSettings(telegramID int64, settings *DigestSettings) error
+	UpdateDigestSentAt(telegramID int64, sentAt time.Time) error
 
 	CreateOAuth2State(state, codeVerifier string, telegramID int64) error
 	GetOAuth2State(state string) (*OAuth2State, error)
 
+	SaveUnsubscription(unsubscription *Unsubscription) error
+	GetUnsubscriptions(telegramID int64) ([]*Unsubscription, error)
+
+	CreateForumTopic(topic *ForumTopic) error
+	GetForumTopic(telegramID int64, maskedEmailID string) (*ForumTopic, error)
+	GetForumTopicByEmail(telegramID int64, email string) (*ForumTopic, error)
+
+	IsBreachNotified(telegramID int64, breachName string) (bool, error)
+	SaveNotifiedBreach(telegramID int64, breachName string, notifiedAt time.Time) error
+
+	BlockDomain(telegramID int64, domain string, blockedAt time.Time) error
+	UnblockDomain(telegramID int64, domain string) error
+	IsDomainBlocked(telegramID int64, domain string) (bool, error)
+	GetBlockedDomains(telegramID int64) ([]*BlockedDomain, error)
+
+	SaveMaskedEmailEvent(telegramID int64, event *MaskedEmailEvent) error
+	GetMaskedEmailEvents(telegramID int64, maskedEmailID string) ([]*MaskedEmailEvent, error)
+	GetMaskedEmailEventCounts(telegramID int64) (map[MaskedEmailEventType]int, error)
+	GetMaskedEmailIDsWithEvent(telegramID int64, eventType MaskedEmailEventType) (map[string]bool, error)
+
+	CreateSelection(selection *Selection) error
+	GetSelection(chatID int64, messageID int) (*Selection, error)
+	UpdateSelection(selection *Selection) error
+	DeleteSelection(chatID int64, messageID int) error
+	DeleteSelectionsCreatedBefore(createdAt time.Time) error
+
+	AddMaskedEmailsLabel(telegramID int64, ids []string, label string, createdAt time.Time) error
+	RemoveMaskedEmailsLabel(telegramID int64, ids []string, label string) error
+	GetMaskedEmailLabels(telegramID int64) (map[string][]string, error)
+
+	CreateUndo(undo *Undo) (int64, error)
+	GetUndo(id int64) (*Undo, error)
+	DeleteUndo(id int64) error
+	DeleteUndosCreatedBefore(createdAt time.Time) error
+
+	SaveMaskedEmailMessage(message *MaskedEmailMessage) error
+	GetMaskedEmailMessage(chatID int64, messageID int) (*MaskedEmailMessage, error)
+	DeleteMaskedEmailMessagesCreatedBefore(createdAt time.Time) error
+
+	CallbackStore
+	ConversationStore
+
 	Close() error
 	NewTokenSource(baseTokenSource oauth2.TokenSource, telegramID int64) oauth2.TokenSource
 }
 
+// CallbackStore keeps what Telegram buttons do, so that the buttons only carry a token.
+type CallbackStore interface {
+	SaveCallback(callback *Callback) error
+	GetCallback(token string) (*Callback, error)
+	DeleteCallbacksExpiredBefore(expiresAt time.Time) error
+}
+
+// ConversationStore keeps dialogs in progress, so that they survive a restart.
+type ConversationStore interface {
+	SaveConversation(conversation *Conversation) error
+	GetConversation(chatID, telegramID int64) (*Conversation, error)
+	DeleteConversation(chatID, telegramID int64) error
+	DeleteConversationsExpiredBefore(expiresAt time.Time) error
+}
+
 type MaskingEmail interface {
-	CreateMaskedEmailFromURL(ctx context.Context, tokenSrc oauth2.TokenSource, url *url.URL) (*MaskedEmail, error)
-	CreateMaskedEmailWithPrefix(ctx context.Context, tokenSrc oauth2.TokenSource, prefix string) (*MaskedEmail, error)
+	CreateMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, create *MaskedEmailCreate) (*MaskedEmail, error)
+	CreateMaskedEmailsFromURLs(ctx context.Context, tokenSrc oauth2.TokenSource, urls []*url.URL) ([]*MaskedEmail, error)
 	EnableMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, id string) error
+	GetMaskedEmails(ctx context.Context, tokenSrc oauth2.TokenSource) ([]*MaskedEmail, error)
+	GetMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, id string) (*MaskedEmail, error)
+	SetMaskedEmailStates(ctx context.Context, tokenSrc oauth2.TokenSource, states map[string]MaskedEmailState) (*BatchResult, error)
+	UpdateMaskedEmails(ctx context.Context, tokenSrc oauth2.TokenSource, updates map[string]*MaskedEmailUpdate) (*BatchResult, error)
+	GetMails(ctx context.Context, tokenSrc oauth2.TokenSource, filter *MailFilter, limit int) ([]*Mail, error)
+	GetMail(ctx context.Context, tokenSrc oauth2.TokenSource, id string) (*Mail, error)
+	CountMails(ctx context.Context, tokenSrc oauth2.TokenSource, filter *MailFilter) (int, error)
+	SendMail(ctx context.Context, tokenSrc oauth2.TokenSource, mail *OutgoingMail) error
 	GetOAuth2Config() *oauth2.Config
 }
 
+type Unsubscriber interface {
+	OneClickUnsubscribe(ctx context.Context, u *url.URL) error
+}
+
+type Breaches interface {
+	GetBreaches() ([]*Breach, error)
+}
+
 type Delivery interface {
 	ListenAndServe() error
 	Shutdown(ctx context.Context) error
@@ -33,4 +115,10 @@ type Delivery interface {
 
 type Telegram interface {
 	SendMessage(telegramID int64, languageCode, messageID string) error
+	IsForum(chatID int64) (bool, error)
+	CreateTopic(chatID int64, name string) (int, error)
+	SendTopicMessage(chatID int64, threadID int, languageCode, messageID string, templateData map[string]interface{}) error
+	SendMailPreview(chatID int64, threadID int, languageCode string, mail *Mail) error
+	SendDigest(telegramID int64, languageCode string, digest *Digest) error
+	SendBreachAlert(telegramID int64, languageCode string, alert *BreachAlert) error
 }
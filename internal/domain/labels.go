@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// labelPattern keeps labels to one short word, so they fit into callback data.
+var labelPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]{1,32}$`)
+
+func normalizeLabel(label string) (string, error) {
+	label = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(label), "#"))
+	if !labelPattern.MatchString(label) {
+		return "", ErrInvalidLabel
+	}
+
+	return label, nil
+}
+
+// splitLabels cuts trailing #labels off a message, "https://x.com #shopping" gives the link and [shopping].
+func splitLabels(text string) (string, []string, error) {
+	fields := strings.Fields(text)
+
+	i := len(fields)
+	for i > 0 && strings.HasPrefix(fields[i-1], "#") {
+		i--
+	}
+
+	// Nothing but labels is not a link with labels
+	if i == 0 || i == len(fields) {
+		return text, nil, nil
+	}
+
+	labels := make([]string, 0, len(fields)-i)
+	for _, field := range fields[i:] {
+		label, err := normalizeLabel(field)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+
+	return strings.Join(fields[:i], " "), labels, nil
+}
+
+// withLabels fills labels of the given masked emails.
+func (s *service) withLabels(telegramID int64, maskedEmails []*MaskedEmail) error {
+	labels, err := s.db.GetMaskedEmailLabels(telegramID)
+	if err != nil {
+		return err
+	}
+
+	for _, maskedEmail := range maskedEmails {
+		maskedEmail.Labels = labels[maskedEmail.ID]
+	}
+
+	return nil
+}
+
+func (s *service) Labels(telegramID int64) ([]string, error) {
+	labels, err := s.db.GetMaskedEmailLabels(telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	for _, maskedEmailLabels := range labels {
+		for _, label := range maskedEmailLabels {
+			if !slices.Contains(result, label) {
+				result = append(result, label)
+			}
+		}
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// AddMaskedEmailsLabel labels the masked emails, the change can be undone like state changes.
+func (s *service) AddMaskedEmailsLabel(telegramID int64, ids []string, label string) (*BatchResult, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := s.db.GetMaskedEmailLabels(telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Already labelled addresses are left untouched, so undo doesn't strip labels they had before
+	undo := &Undo{
+		TelegramID: telegramID,
+		Label:      label,
+		CreatedAt:  time.Now().UTC(),
+	}
+	for _, id := range ids {
+		if !slices.Contains(labels[id], label) {
+			undo.Items = append(undo.Items, &UndoItem{MaskedEmailID: id})
+		}
+	}
+
+	if err := s.db.AddMaskedEmailsLabel(telegramID, ids, label, undo.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{Succeeded: slices.Clone(ids)}
+	sort.Strings(result.Succeeded)
+
+	if len(undo.Items) > 0 {
+		result.UndoID = s.journalUndo(undo)
+	}
+
+	return result, nil
+}
+
+func (s *service) RemoveMaskedEmailLabel(telegramID int64, id string, label string) error {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return err
+	}
+
+	return s.db.RemoveMaskedEmailsLabel(telegramID, []string{id}, label)
+}
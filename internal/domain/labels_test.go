@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeLabel(t *testing.T) {
+	tests := []struct {
+		label   string
+		want    string
+		wantErr error
+	}{
+		{"shopping", "shopping", nil},
+		{"#Shopping", "shopping", nil},
+		{"  #work_2-a ", "work_2-a", nil},
+		{"#Покупки", "покупки", nil},
+		{"", "", ErrInvalidLabel},
+		{"#", "", ErrInvalidLabel},
+		{"##work", "", ErrInvalidLabel},
+		{"two words", "", ErrInvalidLabel},
+		{"work:home", "", ErrInvalidLabel},
+		{"abcdefghijklmnopqrstuvwxyz012345", "abcdefghijklmnopqrstuvwxyz012345", nil},
+		{"abcdefghijklmnopqrstuvwxyz0123456", "", ErrInvalidLabel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			got, err := normalizeLabel(tt.label)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("normalizeLabel(%q) error = %v, want %v", tt.label, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeLabel(%q) = %q, want %q", tt.label, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantText   string
+		wantLabels []string
+		wantErr    error
+	}{
+		{"no labels", "https://shop.com", "https://shop.com", nil, nil},
+		{"labels", "https://shop.com #Shopping #work", "https://shop.com", []string{"shopping", "work"}, nil},
+		{"duplicates", "shop.com #a #A #a", "shop.com", []string{"a"}, nil},
+		{"extra spaces", "  shop.com \n #a  ", "shop.com", []string{"a"}, nil},
+		{"words kept", "my shop.com #a", "my shop.com", []string{"a"}, nil},
+		{"label in the middle", "#a shop.com", "#a shop.com", nil, nil},
+		{"only labels", "#a #b", "#a #b", nil, nil},
+		{"empty", "", "", nil, nil},
+		{"bare hash", "shop.com #", "", nil, ErrInvalidLabel},
+		{"invalid label", "shop.com #a:b", "", nil, ErrInvalidLabel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, labels, err := splitLabels(tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("splitLabels(%q) error = %v, want %v", tt.text, err, tt.wantErr)
+			}
+			if text != tt.wantText || !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("splitLabels(%q) = %q, %q, want %q, %q", tt.text, text, labels, tt.wantText, tt.wantLabels)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "errors"
+
+// LanguageCode returns the language the user has chosen, empty when the user hasn't chosen any.
+// The language stored at /start is only a snapshot of the client one, interactive replies follow the client itself instead.
+func (s *service) LanguageCode(telegramID int64) (string, error) {
+	user, err := s.db.GetUser(telegramID)
+	if errors.Is(err, ErrNoUser) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !user.LanguageChosen {
+		return "", nil
+	}
+
+	return user.LanguageCode, nil
+}
+
+// ChooseLanguageCode stores the language picked by the user, /start keeps it from then on.
+func (s *service) ChooseLanguageCode(telegramID int64, languageCode string) error {
+	return s.db.ChooseLanguageCode(telegramID, languageCode)
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+const mailsLimit = 5
+
+func parseAddress(address string) (string, error) {
+	addr, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", ErrInvalidAddress
+	}
+
+	return addr.Address, nil
+}
+
+func (s *service) Mails(telegramID int64, maskedEmail string) ([]*Mail, error) {
+	address, err := parseAddress(maskedEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.email.GetMails(ctx, tokenSrc, &MailFilter{To: address}, mailsLimit)
+}
+
+// maskedEmailByAddress returns the masked email with one of the addresses, nil when none of them is a masked email.
+func maskedEmailByAddress(maskedEmails []*MaskedEmail, addresses ...string) *MaskedEmail {
+	for _, address := range addresses {
+		for _, maskedEmail := range maskedEmails {
+			if strings.EqualFold(maskedEmail.Email, address) {
+				return maskedEmail
+			}
+		}
+	}
+
+	return nil
+}
+
+// recipientMaskedEmail returns the masked email of the user the mail has been received by.
+// Lists and mails with several recipients don't put the masked email first, so every To and Cc recipient is checked.
+func (s *service) recipientMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, m *Mail) (*MaskedEmail, error) {
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmail := maskedEmailByAddress(maskedEmails, append(append([]string{}, m.To...), m.Cc...)...)
+	if maskedEmail == nil || maskedEmail.State == MaskedEmailStateDeleted {
+		return nil, ErrInvalidAddress
+	}
+
+	return maskedEmail, nil
+}
+
+func (s *service) ComposeMail(telegramID int64, from, to string) (*Draft, error) {
+	fromAddress, err := parseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toAddress, err := parseAddress(to)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mail goes out only from active masked emails, any other address would become a Fastmail identity of the user
+	maskedEmail := maskedEmailByAddress(maskedEmails, fromAddress)
+	if maskedEmail == nil || !maskedEmail.IsActive() {
+		return nil, ErrInvalidAddress
+	}
+
+	return &Draft{
+		TelegramID: telegramID,
+		From:       maskedEmail.Email,
+		To:         toAddress,
+	}, nil
+}
+
+func (s *service) ComposeReply(telegramID int64, mailID string) (*Draft, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.email.GetMail(ctx, tokenSrc, mailID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.From == "" {
+		return nil, ErrInvalidAddress
+	}
+
+	// The mail has been received by the masked address, so we reply from it
+	maskedEmail, err := s.recipientMaskedEmail(ctx, tokenSrc, m)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := m.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+
+	return &Draft{
+		TelegramID: telegramID,
+		From:       maskedEmail.Email,
+		To:         m.From,
+		Subject:    subject,
+		InReplyTo:  m.MessageID,
+		References: append(m.References, m.MessageID...),
+	}, nil
+}
+
+func (s *service) SendDraft(draft *Draft, body string) error {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, draft.TelegramID)
+	if err != nil {
+		return err
+	}
+
+	return s.email.SendMail(ctx, tokenSrc, &OutgoingMail{
+		From:       draft.From,
+		To:         draft.To,
+		Subject:    strings.TrimSpace(draft.Subject),
+		Body:       body,
+		InReplyTo:  draft.InReplyTo,
+		References: draft.References,
+	})
+}
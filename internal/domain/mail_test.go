@@ -0,0 +1,35 @@
+package domain
+
+import "testing"
+
+func TestMaskedEmailByAddress(t *testing.T) {
+	maskedEmails := []*MaskedEmail{
+		{ID: "shop", Email: "shop.123@fastmail.com"},
+		{ID: "news", Email: "news.456@fastmail.com"},
+	}
+
+	tests := []struct {
+		name      string
+		addresses []string
+		want      string
+	}{
+		{"none", nil, ""},
+		{"single", []string{"shop.123@fastmail.com"}, "shop"},
+		{"case", []string{"Shop.123@FastMail.com"}, "shop"},
+		{"list first", []string{"list@example.com", "news.456@fastmail.com"}, "news"},
+		{"preferred order", []string{"news.456@fastmail.com", "shop.123@fastmail.com"}, "news"},
+		{"foreign", []string{"someone@example.com"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := maskedEmailByAddress(maskedEmails, tt.addresses...)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("maskedEmailByAddress(%v) = %s, want nil", tt.addresses, got.ID)
+			case tt.want != "" && (got == nil || got.ID != tt.want):
+				t.Errorf("maskedEmailByAddress(%v) = %v, want %s", tt.addresses, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// maskedEmailMessageLifetime is how long replies to a bot message keep resolving the masked email it shows.
+const maskedEmailMessageLifetime = 90 * 24 * time.Hour
+
+func (s *service) SaveMaskedEmailMessage(telegramID, chatID int64, messageID int, maskedEmail *MaskedEmail) error {
+	return s.db.SaveMaskedEmailMessage(&MaskedEmailMessage{
+		ChatID:        chatID,
+		MessageID:     messageID,
+		TelegramID:    telegramID,
+		MaskedEmailID: maskedEmail.ID,
+		Email:         maskedEmail.Email,
+		CreatedAt:     time.Now().UTC(),
+	})
+}
+
+// MaskedEmailMessage returns the masked email shown by a bot message, if the message was sent to the user.
+func (s *service) MaskedEmailMessage(telegramID, chatID int64, messageID int) (*MaskedEmailMessage, error) {
+	message, err := s.db.GetMaskedEmailMessage(chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group members can reply to the message too, but the address belongs to one of them
+	if message.TelegramID != telegramID {
+		return nil, ErrNoMaskedEmailMessage
+	}
+
+	return message, nil
+}
+
+// PurgeMaskedEmailMessages forgets the masked emails shown by messages older than their lifetime.
+func (s *service) PurgeMaskedEmailMessages() error {
+	return s.db.DeleteMaskedEmailMessagesCreatedBefore(time.Now().UTC().Add(-maskedEmailMessageLifetime))
+}
+
+// SetMaskedEmailDescription replaces the description, the previous one can be restored with undo.
+func (s *service) SetMaskedEmailDescription(telegramID int64, id, description string) (*BatchResult, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	prior, err := s.email.GetMaskedEmail(ctx, tokenSrc, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateMaskedEmails(
+		ctx,
+		tokenSrc,
+		telegramID,
+		map[string]*MaskedEmailUpdate{id: {Description: &description}},
+		map[string]*MaskedEmail{id: prior},
+	)
+}
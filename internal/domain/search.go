@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Weights rank where a match was found, a hit in the site counts more than one in the address.
+const (
+	searchWeightHost        = 10
+	searchWeightPrefix      = 10
+	searchWeightLabel       = 9
+	searchWeightDescription = 8
+	searchWeightEmail       = 7
+)
+
+// fuzzyScore rates how well the query matches the text, zero means no match.
+// Substrings beat scattered letters, and the earlier and tighter the match the better.
+func fuzzyScore(query, text string) int {
+	query = strings.ToLower(query)
+	text = strings.ToLower(text)
+	if query == "" || text == "" {
+		return 0
+	}
+
+	if i := strings.Index(text, query); i >= 0 {
+		// Positions count letters, so Cyrillic matches rank the same as Latin ones
+		prev, _ := utf8.DecodeLastRuneInString(text[:i])
+		switch {
+		case i == 0:
+			return 100
+		case !isWordRune(prev):
+			return 90
+		default:
+			return 80 - min(utf8.RuneCountInString(text[:i]), 20)
+		}
+	}
+
+	// Letters in order with gaps between them, e.g. "amzn" in "amazon"
+	runes := []rune(text)
+	gaps, start, pos := 0, -1, 0
+	for _, r := range query {
+		i := slices.Index(runes[pos:], r)
+		if i < 0 {
+			return 0
+		}
+		if start < 0 {
+			start = pos + i
+		} else {
+			gaps += i
+		}
+		pos += i + 1
+	}
+
+	return max(50-gaps*5-min(start, 10), 1)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// searchScore is the best weighted match of the query among the masked email fields.
+func searchScore(query string, maskedEmail *MaskedEmail) int {
+	score := 0
+	match := func(text string, weight int) {
+		score = max(score, fuzzyScore(query, text)*weight)
+	}
+
+	match(forDomainHost(maskedEmail.ForDomain), searchWeightHost)
+	match(maskedEmail.EmailPrefix, searchWeightPrefix)
+	match(maskedEmail.Description, searchWeightDescription)
+	match(maskedEmail.Email, searchWeightEmail)
+	for _, label := range maskedEmail.Labels {
+		match(label, searchWeightLabel)
+	}
+
+	return score
+}
+
+// SearchMaskedEmails returns active masked emails matching the query, best matches first.
+// An empty query lists addresses that got mail most recently.
+func (s *service) SearchMaskedEmails(telegramID int64, query string, limit int) ([]*MaskedEmail, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.withLabels(telegramID, maskedEmails); err != nil {
+		return nil, err
+	}
+
+	query = strings.TrimSpace(query)
+	scores := make(map[string]int, len(maskedEmails))
+	result := make([]*MaskedEmail, 0)
+	for _, maskedEmail := range maskedEmails {
+		if !maskedEmail.IsActive() {
+			continue
+		}
+
+		if query != "" {
+			score := searchScore(query, maskedEmail)
+			if score == 0 {
+				continue
+			}
+			scores[maskedEmail.ID] = score
+		}
+
+		result = append(result, maskedEmail)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if scores[result[i].ID] != scores[result[j].ID] {
+			return scores[result[i].ID] > scores[result[j].ID]
+		}
+
+		if !result[i].LastMessageAt.Equal(result[j].LastMessageAt) {
+			return result[i].LastMessageAt.After(result[j].LastMessageAt)
+		}
+
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result[:min(len(result), limit)], nil
+}
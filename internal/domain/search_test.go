@@ -0,0 +1,37 @@
+package domain
+
+import "testing"
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		text  string
+		want  int
+	}{
+		{"empty query", "", "shop.com", 0},
+		{"empty text", "shop", "", 0},
+		{"prefix", "shop", "shop.com", 100},
+		{"case", "SHOP", "Shop.com", 100},
+		{"word start", "shop", "my-shop.com", 90},
+		{"inside a word", "shop", "myshop.com", 78},
+		{"far inside a word", "shop", "averyveryverylongnameshop", 60},
+		{"scattered", "amzn", "amazon", 40},
+		{"scattered late", "amzn", "myamazon", 38},
+		{"wide gaps", "ab", "a-------------b", 1},
+		{"letters out of order", "nzma", "amazon", 0},
+		{"missing letter", "shopx", "shop.com", 0},
+		{"cyrillic prefix", "маг", "Магазин", 100},
+		{"cyrillic word start", "магазин", "мой магазин", 90},
+		{"cyrillic inside a word", "магазин", "супермагазин", 75},
+		{"cyrillic scattered", "мгз", "магазин", 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fuzzyScore(tt.query, tt.text); got != tt.want {
+				t.Errorf("fuzzyScore(%q, %q) = %d, want %d", tt.query, tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// selectionLifetime is how long a multi-select keyboard keeps working after it has been sent.
+const selectionLifetime = 7 * 24 * time.Hour
+
+func (s *service) CreateSelection(selection *Selection) error {
+	// Forget keyboards nobody is going to press anymore
+	if err := s.db.DeleteSelectionsCreatedBefore(time.Now().UTC().Add(-selectionLifetime)); err != nil {
+		return err
+	}
+
+	selection.CreatedAt = time.Now().UTC()
+	return s.db.CreateSelection(selection)
+}
+
+func (s *service) Selection(telegramID, chatID int64, messageID int) (*Selection, error) {
+	selection, err := s.db.GetSelection(chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keyboards in groups are visible to everyone, but belong to the one who asked
+	if selection.TelegramID != telegramID {
+		return nil, ErrNoSelection
+	}
+
+	return selection, nil
+}
+
+func (s *service) UpdateSelection(selection *Selection) error {
+	return s.db.UpdateSelection(selection)
+}
+
+func (s *service) DeleteSelection(chatID int64, messageID int) error {
+	return s.db.DeleteSelection(chatID, messageID)
+}
@@ -13,30 +13,93 @@ import (
 	"golang.org/x/oauth2"
 	"io"
 	"net/url"
-	"regexp"
+	"time"
 )
 
 type Service interface {
 	StartCommand(telegramID int64, languageCode string) (string, error)
+	IsAuthorized(telegramID int64) (bool, error)
+	LanguageCode(telegramID int64) (string, error)
+	ChooseLanguageCode(telegramID int64, languageCode string) error
 	HandleRedirect(ctx context.Context, code, state string) error
 	GenerateMaskedEmail(telegramID int64, messageText string) (*MaskedEmail, error)
-	Prefix(telegramID int64, prefix string) (*MaskedEmail, error)
+	GenerateMaskedEmailForBlockedDomain(telegramID int64, messageText string) (*MaskedEmail, error)
+	CreateMaskedEmail(telegramID int64, request *MaskedEmailRequest) (*MaskedEmail, error)
+	CreateMaskedEmailForBlockedDomain(telegramID int64, request *MaskedEmailRequest) (*MaskedEmail, error)
 	EnableMaskedEmail(telegramID int64, id string) error
+	MaskedEmailDetails(telegramID int64, id string) (*MaskedEmailDetails, error)
+	MaskedEmailDetailsByAddress(telegramID int64, email string) (*MaskedEmailDetails, error)
+
+	Mails(telegramID int64, maskedEmail string) ([]*Mail, error)
+	ComposeMail(telegramID int64, from, to string) (*Draft, error)
+	ComposeReply(telegramID int64, mailID string) (*Draft, error)
+
+	CreateSelection(selection *Selection) error
+	Selection(telegramID, chatID int64, messageID int) (*Selection, error)
+	UpdateSelection(selection *Selection) error
+	DeleteSelection(chatID int64, messageID int) error
+	SendDraft(draft *Draft, body string) error
+
+	Unsubscribe(telegramID int64, mailID string) (*Unsubscription, error)
+	Unsubscriptions(telegramID int64) ([]*Unsubscription, error)
+
+	ConnectForum(telegramID, chatID int64) error
+	DisconnectForum(telegramID int64) error
+	PollForumMails() error
+
+	DigestSettings(telegramID int64) (*DigestSettings, error)
+	UpdateDigestSettings(telegramID int64, settings *DigestSettings) error
+	SendDigest(telegramID int64) error
+	SendDigests() error
+
+	StaleMaskedEmails(telegramID int64, months int) (*StaleMaskedEmails, error)
+	SetMaskedEmailsState(telegramID int64, ids []string, state MaskedEmailState) (*BatchResult, error)
+
+	RotateMaskedEmail(telegramID int64, id string) (*MaskedEmail, *BatchResult, error)
+	CheckBreaches() error
+
+	MaskedEmails(telegramID int64, filter *MaskedEmailFilter) ([]*MaskedEmail, error)
+	SearchMaskedEmails(telegramID int64, query string, limit int) ([]*MaskedEmail, error)
+	SaveMaskedEmailMessage(telegramID, chatID int64, messageID int, maskedEmail *MaskedEmail) error
+	MaskedEmailMessage(telegramID, chatID int64, messageID int) (*MaskedEmailMessage, error)
+	PurgeMaskedEmailMessages() error
+	PurgeCallbacks() error
+	SetMaskedEmailDescription(telegramID int64, id, description string) (*BatchResult, error)
+	Labels(telegramID int64) ([]string, error)
+	AddMaskedEmailsLabel(telegramID int64, ids []string, label string) (*BatchResult, error)
+	RemoveMaskedEmailLabel(telegramID int64, id string, label string) error
+	ExportMaskedEmails(telegramID int64, format ExportFormat, filter *MaskedEmailFilter) (*Export, error)
+	ImportCandidates(telegramID int64, r io.Reader) ([]*ImportCandidate, error)
+	ImportMaskedEmails(telegramID int64, urls []string) ([]*ImportedMaskedEmail, error)
+	ServiceGroups(telegramID int64) ([]*ServiceGroup, error)
+	Stats(telegramID int64) (*Stats, error)
+	KeepMaskedEmail(telegramID int64, id string) (*BatchResult, error)
+	Undo(telegramID int64, undoID int64) (*BatchResult, error)
+
+	BlockDomain(telegramID int64, domain string) (*BlockedDomain, *BatchResult, error)
+	UnblockDomain(telegramID int64, domain string) error
+	BlockedDomains(telegramID int64) ([]*BlockedDomain, error)
 }
 
 type service struct {
-	logger   *zap.Logger
-	db       Database
-	email    MaskingEmail
-	telegram Telegram
+	logger       *zap.Logger
+	config       *Config
+	db           Database
+	email        MaskingEmail
+	telegram     Telegram
+	unsubscriber Unsubscriber
+	breaches     Breaches
 }
 
-func NewService(logger *zap.Logger, db Database, email MaskingEmail, telegram Telegram) Service {
+func NewService(logger *zap.Logger, config *Config, db Database, email MaskingEmail, telegram Telegram, unsubscriber Unsubscriber, breaches Breaches) Service {
 	return &service{
-		logger:   logger,
-		db:       db,
-		email:    email,
-		telegram: telegram,
+		logger:       logger,
+		config:       config,
+		db:           db,
+		email:        email,
+		telegram:     telegram,
+		unsubscriber: unsubscriber,
+		breaches:     breaches,
 	}
 }
 
@@ -50,15 +113,52 @@ func randomBytesInHex(count int) (string, error) {
 	return hex.EncodeToString(buf), nil
 }
 
+// tokenSource returns a token source for the Fastmail account of the given user.
+func (s *service) tokenSource(ctx context.Context, telegramID int64) (oauth2.TokenSource, error) {
+	user, err := s.db.GetUser(telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.FastmailToken == nil {
+		return nil, ErrNoToken
+	}
+
+	return s.db.NewTokenSource(
+		s.email.GetOAuth2Config().TokenSource(ctx, user.FastmailToken),
+		user.TelegramID,
+	), nil
+}
+
+// IsAuthorized reports whether the user has connected a Fastmail account.
+func (s *service) IsAuthorized(telegramID int64) (bool, error) {
+	user, err := s.db.GetUser(telegramID)
+	if errors.Is(err, ErrNoUser) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return user.FastmailToken != nil, nil
+}
+
 func (s *service) StartCommand(telegramID int64, languageCode string) (string, error) {
 	if err := s.db.CreateUser(telegramID, languageCode); err != nil {
 		if !errors.Is(err, ErrSqliteUserAlreadyExists) {
 			return "", err
 		}
 
-		if err := s.db.UpdateLanguageCode(telegramID, languageCode); err != nil {
+		user, err := s.db.GetUser(telegramID)
+		if err != nil {
 			return "", err
 		}
+
+		if !user.LanguageChosen {
+			if err := s.db.UpdateLanguageCode(telegramID, languageCode); err != nil {
+				return "", err
+			}
+		}
 	}
 
 	codeVerifier, err := randomBytesInHex(32)
@@ -127,69 +227,75 @@ func (s *service) HandleRedirect(ctx context.Context, code, state string) error
 }
 
 func (s *service) GenerateMaskedEmail(telegramID int64, messageText string) (*MaskedEmail, error) {
-	user, err := s.db.GetUser(telegramID)
-	if err != nil {
-		return nil, err
-	}
-
-	if user.FastmailToken == nil {
-		return nil, ErrNoToken
-	}
+	return s.generateMaskedEmail(telegramID, messageText, false)
+}
 
-	ctx := context.Background()
-	tokenSrc := s.db.NewTokenSource(
-		s.email.GetOAuth2Config().TokenSource(ctx, user.FastmailToken),
-		user.TelegramID,
-	)
+func (s *service) GenerateMaskedEmailForBlockedDomain(telegramID int64, messageText string) (*MaskedEmail, error) {
+	return s.generateMaskedEmail(telegramID, messageText, true)
+}
 
-	u, err := url.Parse(messageText)
-	if err != nil || regexp.MustCompile(`[a-z0-9_]+`).FindString(u.String()) == messageText {
-		s.logger.Error("Error while parsing url for domain!", zap.Error(err))
-		return s.email.CreateMaskedEmailWithPrefix(ctx, tokenSrc, messageText)
+func (s *service) generateMaskedEmail(telegramID int64, messageText string, allowBlocked bool) (*MaskedEmail, error) {
+	messageText, labels, err := splitLabels(messageText)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.email.CreateMaskedEmailFromURL(ctx, tokenSrc, u)
+	target := ParseMaskedEmailTarget(messageText)
+	return s.createMaskedEmail(telegramID, &MaskedEmailCreate{
+		ForDomain:   target.ForDomain(),
+		EmailPrefix: target.Prefix,
+	}, labels, allowBlocked, "")
 }
 
-func (s *service) Prefix(telegramID int64, prefix string) (*MaskedEmail, error) {
-	user, err := s.db.GetUser(telegramID)
+// createMaskedEmail creates the masked email, records its creation and labels it, replaces is the address it takes over from.
+func (s *service) createMaskedEmail(telegramID int64, create *MaskedEmailCreate, labels []string, allowBlocked bool, replaces string) (*MaskedEmail, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
 	if err != nil {
 		return nil, err
 	}
 
-	if user.FastmailToken == nil {
-		return nil, ErrNoToken
+	if !allowBlocked && create.ForDomain != "" {
+		if u, err := url.Parse(create.ForDomain); err == nil {
+			if err := s.checkDomainBlocked(telegramID, u.Hostname()); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	ctx := context.Background()
-	tokenSrc := s.db.NewTokenSource(
-		s.email.GetOAuth2Config().TokenSource(ctx, user.FastmailToken),
-		user.TelegramID,
-	)
-
-	maskedEmail, err := s.email.CreateMaskedEmailWithPrefix(ctx, tokenSrc, prefix)
+	maskedEmail, err := s.email.CreateMaskedEmail(ctx, tokenSrc, create)
 	if err != nil {
 		return nil, err
 	}
 
+	s.recordEvent(telegramID, maskedEmail.ID, MaskedEmailEventCreated, replaces)
+	s.createForumTopic(telegramID, maskedEmail)
+
+	// The address exists already, a lost label isn't worth failing the whole request
+	for _, label := range labels {
+		if err := s.db.AddMaskedEmailsLabel(telegramID, []string{maskedEmail.ID}, label, time.Now().UTC()); err != nil {
+			s.logger.Error("Error while labelling a masked email!", zap.Error(err))
+			continue
+		}
+		maskedEmail.Labels = append(maskedEmail.Labels, label)
+	}
+
 	return maskedEmail, nil
 }
 
 func (s *service) EnableMaskedEmail(telegramID int64, id string) error {
-	user, err := s.db.GetUser(telegramID)
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
 	if err != nil {
 		return err
 	}
 
-	if user.FastmailToken == nil {
-		return ErrNoToken
+	if err := s.email.EnableMaskedEmail(ctx, tokenSrc, id); err != nil {
+		return err
 	}
 
-	ctx := context.Background()
-	tokenSrc := s.db.NewTokenSource(
-		s.email.GetOAuth2Config().TokenSource(ctx, user.FastmailToken),
-		user.TelegramID,
-	)
+	s.recordEvent(telegramID, id, MaskedEmailEventEnabled, "")
+	s.notifyForumTopic(telegramID, id, "TelegramTopicEmailEnabled")
 
-	return s.email.EnableMaskedEmail(ctx, tokenSrc, id)
+	return nil
 }
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"context"
+	"sort"
+)
+
+// serviceDomain normalizes forDomain, so addresses created for subdomains of a site end up in one group.
+func serviceDomain(forDomain string) string {
+	if forDomain == "" {
+		return ""
+	}
+
+	return baseDomain(forDomainHost(forDomain))
+}
+
+// groupByService groups masked emails that aren't deleted by the normalized forDomain.
+func groupByService(maskedEmails []*MaskedEmail) []*ServiceGroup {
+	byDomain := make(map[string]*ServiceGroup)
+	for _, maskedEmail := range maskedEmails {
+		if maskedEmail.State == MaskedEmailStateDeleted {
+			continue
+		}
+
+		domain := serviceDomain(maskedEmail.ForDomain)
+		group, ok := byDomain[domain]
+		if !ok {
+			group = &ServiceGroup{
+				Domain: domain,
+				States: make(map[MaskedEmailState]int),
+			}
+			byDomain[domain] = group
+		}
+
+		group.MaskedEmails = append(group.MaskedEmails, maskedEmail)
+		group.States[maskedEmail.State]++
+	}
+
+	groups := make([]*ServiceGroup, 0, len(byDomain))
+	for _, group := range byDomain {
+		sort.Slice(group.MaskedEmails, func(i, j int) bool {
+			return group.MaskedEmails[i].CreatedAt.Before(group.MaskedEmails[j].CreatedAt)
+		})
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].MaskedEmails) != len(groups[j].MaskedEmails) {
+			return len(groups[i].MaskedEmails) > len(groups[j].MaskedEmails)
+		}
+		return groups[i].Domain < groups[j].Domain
+	})
+
+	return groups
+}
+
+func (s *service) ServiceGroups(telegramID int64) ([]*ServiceGroup, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupByService(maskedEmails), nil
+}
+
+// KeepMaskedEmail disables every other active masked email created for the same service.
+func (s *service) KeepMaskedEmail(telegramID int64, id string) (*BatchResult, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept *MaskedEmail
+	for _, maskedEmail := range maskedEmails {
+		if maskedEmail.ID == id {
+			kept = maskedEmail
+			break
+		}
+	}
+
+	if kept == nil {
+		return nil, ErrNoMaskedEmail
+	}
+
+	// Addresses without a site have nothing in common but that
+	domain := serviceDomain(kept.ForDomain)
+	if domain == "" {
+		return &BatchResult{}, nil
+	}
+
+	ids := make([]string, 0)
+	for _, maskedEmail := range maskedEmails {
+		if maskedEmail.ID == kept.ID || !maskedEmail.IsActive() || serviceDomain(maskedEmail.ForDomain) != domain {
+			continue
+		}
+
+		ids = append(ids, maskedEmail.ID)
+	}
+
+	if len(ids) == 0 {
+		return &BatchResult{}, nil
+	}
+
+	return s.setMaskedEmailsState(ctx, tokenSrc, telegramID, maskedEmails, ids, MaskedEmailStateDisabled)
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServiceDomain(t *testing.T) {
+	tests := []struct {
+		forDomain string
+		want      string
+	}{
+		{"", ""},
+		{"https://example.com", "example.com"},
+		{"https://accounts.example.com", "example.com"},
+		{"https://www.bbc.co.uk", "bbc.co.uk"},
+		{"https://www.amazon.co.uk", "amazon.co.uk"},
+		{"https://user.github.io", "user.github.io"},
+		{"amazon.com.au", "amazon.com.au"},
+	}
+
+	for _, tt := range tests {
+		if got := serviceDomain(tt.forDomain); got != tt.want {
+			t.Errorf("serviceDomain(%q) = %q, want %q", tt.forDomain, got, tt.want)
+		}
+	}
+}
+
+func TestGroupByService(t *testing.T) {
+	now := time.Now()
+	maskedEmails := []*MaskedEmail{
+		{ID: "bbc-news", ForDomain: "https://news.bbc.co.uk", State: MaskedEmailStateEnabled, CreatedAt: now},
+		{ID: "bbc", ForDomain: "https://www.bbc.co.uk", State: MaskedEmailStateEnabled, CreatedAt: now.Add(-time.Hour)},
+		{ID: "amazon", ForDomain: "https://www.amazon.co.uk", State: MaskedEmailStateEnabled},
+		{ID: "gov", ForDomain: "https://gov.co.uk", State: MaskedEmailStateDisabled},
+		{ID: "deleted", ForDomain: "https://bbc.co.uk", State: MaskedEmailStateDeleted},
+	}
+
+	got := make(map[string][]string)
+	for _, group := range groupByService(maskedEmails) {
+		for _, maskedEmail := range group.MaskedEmails {
+			got[group.Domain] = append(got[group.Domain], maskedEmail.ID)
+		}
+	}
+
+	want := map[string][]string{
+		"bbc.co.uk":    {"bbc", "bbc-news"},
+		"amazon.co.uk": {"amazon"},
+		"gov.co.uk":    {"gov"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByService() = %v, want %v", got, want)
+	}
+}
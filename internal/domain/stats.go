@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	statsMonths      = 12
+	statsTopServices = 5
+	// statsRecentTraffic is how recent the last mail has to be to count an address as in use.
+	statsRecentTraffic = 30 * 24 * time.Hour
+)
+
+// buildStats counts the masked emails, deletedViaBot are the ones the user has deleted through the bot.
+func buildStats(maskedEmails []*MaskedEmail, deletedViaBot map[string]bool, now time.Time) *Stats {
+	stats := &Stats{
+		States: make(map[MaskedEmailState]int),
+	}
+
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	months := make(map[time.Time]*MonthCount, statsMonths)
+	for i := statsMonths - 1; i >= 0; i-- {
+		month := &MonthCount{Month: thisMonth.AddDate(0, -i, 0)}
+		months[month.Month] = month
+		stats.CreatedPerMonth = append(stats.CreatedPerMonth, month)
+	}
+
+	for _, maskedEmail := range maskedEmails {
+		stats.States[maskedEmail.State]++
+
+		createdAt := maskedEmail.CreatedAt.UTC()
+		if month, ok := months[time.Date(createdAt.Year(), createdAt.Month(), 1, 0, 0, 0, 0, time.UTC)]; ok {
+			month.Count++
+		}
+
+		if !maskedEmail.LastMessageAt.IsZero() && now.Sub(maskedEmail.LastMessageAt) < statsRecentTraffic {
+			stats.RecentTraffic++
+		}
+
+		// Fastmail deletes pending addresses that haven't received anything in time, unlike the ones deleted on purpose
+		if maskedEmail.State == MaskedEmailStateDeleted && maskedEmail.LastMessageAt.IsZero() && !deletedViaBot[maskedEmail.ID] {
+			stats.ExpiredUnused++
+		}
+	}
+
+	for _, group := range groupByService(maskedEmails) {
+		if group.Domain == "" {
+			continue
+		}
+
+		stats.TopServices = append(stats.TopServices, &ServiceCount{
+			Domain: group.Domain,
+			Count:  len(group.MaskedEmails),
+		})
+		if len(stats.TopServices) == statsTopServices {
+			break
+		}
+	}
+
+	return stats
+}
+
+func (s *service) Stats(telegramID int64) (*Stats, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	maskedEmails, err := s.email.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedViaBot, err := s.db.GetMaskedEmailIDsWithEvent(telegramID, MaskedEmailEventDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := buildStats(maskedEmails, deletedViaBot, time.Now().UTC())
+
+	stats.Events, err = s.db.GetMaskedEmailEventCounts(telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
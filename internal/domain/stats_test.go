@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildStatsExpiredUnused(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	maskedEmails := []*MaskedEmail{
+		{ID: "expired", State: MaskedEmailStateDeleted, CreatedAt: now.AddDate(0, -1, 0)},
+		{ID: "deleted", State: MaskedEmailStateDeleted, CreatedAt: now.AddDate(0, -1, 0)},
+		{ID: "used", State: MaskedEmailStateDeleted, CreatedAt: now.AddDate(0, -2, 0), LastMessageAt: now.AddDate(0, -1, 0)},
+		{ID: "pending", State: MaskedEmailStatePending, CreatedAt: now},
+	}
+
+	tests := []struct {
+		name          string
+		deletedViaBot map[string]bool
+		want          int
+	}{
+		{"no history", nil, 2},
+		{"deleted on purpose", map[string]bool{"deleted": true}, 1},
+		{"deleted after use", map[string]bool{"used": true}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildStats(maskedEmails, tt.deletedViaBot, now).ExpiredUnused; got != tt.want {
+				t.Errorf("ExpiredUnused = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
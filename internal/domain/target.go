@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	prefixPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+	domainPattern = regexp.MustCompile(`(?i)^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*\.[a-z]{2,}(:\d+)?(/\S*)?$`)
+)
+
+// MaskedEmailTarget is what a message asks a masked email for: a site to bind it to, or just a prefix.
+type MaskedEmailTarget struct {
+	Prefix string
+	// URL is the site origin, nil for a bare prefix
+	URL *url.URL
+}
+
+// ForDomain is the value Fastmail stores for the site, empty for a bare prefix.
+func (t *MaskedEmailTarget) ForDomain() string {
+	if t.URL == nil {
+		return ""
+	}
+
+	return t.URL.String()
+}
+
+// String is the text parsing back into the same target.
+func (t *MaskedEmailTarget) String() string {
+	if t.URL != nil {
+		return t.URL.String()
+	}
+
+	return t.Prefix
+}
+
+// ParseMaskedEmailTarget reads a prefix, a link or a bare domain. Text that is none of these
+// gives an empty target, Fastmail picks a random prefix for it then.
+func ParseMaskedEmailTarget(text string) *MaskedEmailTarget {
+	text = strings.TrimSpace(text)
+	if prefixPattern.MatchString(text) {
+		return &MaskedEmailTarget{Prefix: text}
+	}
+
+	u, ok := siteURL(text)
+	if !ok {
+		// Links of apps and other schemes are still bound to the site
+		if parsed, err := url.Parse(text); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+			u, ok = SiteOrigin(parsed), true
+		}
+	}
+	if !ok {
+		return &MaskedEmailTarget{}
+	}
+
+	return &MaskedEmailTarget{
+		Prefix: SiteEmailPrefix(u),
+		URL:    u,
+	}
+}
+
+// siteURL turns a link or a bare domain into a site origin, anything else is rejected.
+func siteURL(s string) (*url.URL, bool) {
+	s = strings.Trim(strings.TrimSpace(s), `"'<>,;`)
+	if s == "" {
+		return nil, false
+	}
+
+	if !strings.Contains(s, "://") {
+		if !domainPattern.MatchString(s) {
+			return nil, false
+		}
+		s = "https://" + s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !strings.Contains(u.Hostname(), ".") {
+		return nil, false
+	}
+
+	return &url.URL{Scheme: u.Scheme, Host: strings.ToLower(u.Host)}, true
+}
+
+// SiteOrigin strips the URL down to the scheme and host, the way it's stored in forDomain.
+func SiteOrigin(u *url.URL) *url.URL {
+	return &url.URL{Scheme: u.Scheme, Host: u.Host}
+}
+
+// SiteEmailPrefix derives an address prefix from the site name.
+func SiteEmailPrefix(u *url.URL) string {
+	emailPrefix := ""
+
+	if _, err := netip.ParseAddr(u.Hostname()); err == nil {
+		emailPrefix = "ipaddr"
+	}
+
+	parts := strings.Split(u.Hostname(), ".")
+	switch len(parts) {
+	case 1:
+		emailPrefix = parts[0]
+	case 2:
+		emailPrefix = parts[0]
+	default:
+		emailPrefix = parts[len(parts)-2]
+	}
+
+	switch emailPrefix {
+	case "fastmail":
+		emailPrefix = "mail"
+	case "github":
+		emailPrefix = "dev"
+	}
+
+	// remove all special characters except underscore
+	return regexp.MustCompile(`[^a-zA-Z0-9_]+`).ReplaceAllString(emailPrefix, "")
+}
@@ -1,11 +1,22 @@
 package domain
 
-import "golang.org/x/oauth2"
+import (
+	"golang.org/x/oauth2"
+	"time"
+)
 
 type User struct {
 	TelegramID    int64
 	FastmailToken *oauth2.Token
 	LanguageCode  string
+	// LanguageChosen is set once the user has picked the language, the Telegram client no longer overrides it
+	LanguageChosen bool
+	ForumChatID    int64
+	ForumPolledAt  time.Time
+	// ForumPolledIDs are mails received at ForumPolledAt that have been posted already
+	ForumPolledIDs []string
+	Digest         DigestSettings
+	DigestSentAt   time.Time
 }
 
 type OAuth2State struct {
@@ -14,7 +25,359 @@ type OAuth2State struct {
 	TelegramID   int64
 }
 
+type MaskedEmailState string
+
+const (
+	MaskedEmailStatePending  MaskedEmailState = "pending"
+	MaskedEmailStateEnabled  MaskedEmailState = "enabled"
+	MaskedEmailStateDisabled MaskedEmailState = "disabled"
+	MaskedEmailStateDeleted  MaskedEmailState = "deleted"
+)
+
 type MaskedEmail struct {
-	ID    string
-	Email string
+	ID            string
+	Email         string
+	State         MaskedEmailState
+	ForDomain     string
+	Description   string
+	URL           string
+	EmailPrefix   string
+	CreatedBy     string
+	CreatedAt     time.Time
+	LastMessageAt time.Time
+	// Labels are kept by the bot, Fastmail knows nothing about them
+	Labels []string
+}
+
+// IsActive reports whether the masked email receives mail or will once it gets the first one.
+func (m *MaskedEmail) IsActive() bool {
+	return m.State == MaskedEmailStateEnabled || m.State == MaskedEmailStatePending
+}
+
+type Mail struct {
+	ID         string
+	From       string
+	To         []string
+	Cc         []string
+	Subject    string
+	Preview    string
+	ReceivedAt time.Time
+	MessageID  []string
+	References []string
+
+	ListUnsubscribe     []string
+	ListUnsubscribePost string
+}
+
+type MailFilter struct {
+	From   string
+	To     string
+	After  time.Time
+	Before time.Time
+	// OldestFirst returns the oldest mails of the filter instead of the newest ones
+	OldestFirst bool
+}
+
+type OutgoingMail struct {
+	From       string
+	To         string
+	Subject    string
+	Body       string
+	InReplyTo  []string
+	References []string
+}
+
+// Draft is a message being composed in Telegram.
+type Draft struct {
+	TelegramID int64
+	From       string
+	To         string
+	Subject    string
+	InReplyTo  []string
+	References []string
+}
+
+type UnsubscribeMethod string
+
+const (
+	UnsubscribeMethodOneClick UnsubscribeMethod = "one-click"
+	UnsubscribeMethodMailto   UnsubscribeMethod = "mailto"
+)
+
+type UnsubscriptionStatus string
+
+const (
+	UnsubscriptionStatusPending  UnsubscriptionStatus = "pending"
+	UnsubscriptionStatusHonoured UnsubscriptionStatus = "honoured"
+	UnsubscriptionStatusIgnored  UnsubscriptionStatus = "ignored"
+)
+
+// Unsubscription is an unsubscribe request sent on behalf of a masked address to a single sender.
+type Unsubscription struct {
+	TelegramID  int64
+	MaskedEmail string
+	Sender      string
+	Method      UnsubscribeMethod
+	RequestedAt time.Time
+	Status      UnsubscriptionStatus
+}
+
+// ForumTopic binds a masked email to its thread in the user's forum supergroup.
+type ForumTopic struct {
+	TelegramID    int64
+	MaskedEmailID string
+	Email         string
+	ChatID        int64
+	ThreadID      int
+}
+
+type DigestSchedule string
+
+const (
+	DigestScheduleOff    DigestSchedule = "off"
+	DigestScheduleDaily  DigestSchedule = "daily"
+	DigestScheduleWeekly DigestSchedule = "weekly"
+)
+
+type DigestSettings struct {
+	Schedule DigestSchedule
+	Timezone string
+	Hour     int
+}
+
+type SenderCount struct {
+	Sender string
+	Count  int
+}
+
+// Leak is a mail received by a masked email from a sender unrelated to the site it was created for.
+type Leak struct {
+	Email     string
+	ForDomain string
+	Sender    string
+}
+
+// Digest summarises masked email activity over a period.
+type Digest struct {
+	Schedule     DigestSchedule
+	Location     *time.Location
+	From         time.Time
+	To           time.Time
+	Created      []*MaskedEmail
+	FirstMail    []*MaskedEmail
+	Expiring     []*MaskedEmail
+	NoisySenders []*SenderCount
+	Leaks        []*Leak
+}
+
+// BatchResult lists masked email IDs that were and were not updated by a batched call.
+type BatchResult struct {
+	Succeeded []string
+	Failed    []string
+	// UndoID references the journaled prior states, zero when there is nothing to undo.
+	UndoID int64
+}
+
+// StaleMaskedEmails groups masked emails that are likely not needed anymore.
+type StaleMaskedEmails struct {
+	NeverUsed []*MaskedEmail
+	Silent    []*MaskedEmail
+	Pending   []*MaskedEmail
+}
+
+// Breach is a publicly known data breach of a service.
+type Breach struct {
+	Name       string
+	Title      string
+	Domain     string
+	BreachDate time.Time
+}
+
+// BreachAlert lists masked emails of a user that were created for a breached service.
+type BreachAlert struct {
+	Breach       *Breach
+	MaskedEmails []*MaskedEmail
+}
+
+// BlockedDomain is a site the user doesn't want to create masked emails for anymore.
+type BlockedDomain struct {
+	Domain    string
+	BlockedAt time.Time
+}
+
+type MaskedEmailEventType string
+
+const (
+	MaskedEmailEventCreated  MaskedEmailEventType = "created"
+	MaskedEmailEventEnabled  MaskedEmailEventType = "enabled"
+	MaskedEmailEventDisabled MaskedEmailEventType = "disabled"
+	MaskedEmailEventDeleted  MaskedEmailEventType = "deleted"
+	MaskedEmailEventRotated  MaskedEmailEventType = "rotated"
+)
+
+// MaskedEmailEvent is a lifecycle change of a masked email made through the bot.
+type MaskedEmailEvent struct {
+	MaskedEmailID string
+	Type          MaskedEmailEventType
+	// Details holds the counterpart address of a rotation
+	Details   string
+	CreatedAt time.Time
+}
+
+// MaskedEmailDetails combines a masked email with its local history.
+type MaskedEmailDetails struct {
+	MaskedEmail *MaskedEmail
+	History     []*MaskedEmailEvent
+}
+
+// ServiceGroup is the inventory of masked emails created for one service.
+type ServiceGroup struct {
+	Domain       string
+	MaskedEmails []*MaskedEmail
+	States       map[MaskedEmailState]int
+}
+
+// Active returns masked emails of the group that still receive mail.
+func (g *ServiceGroup) Active() []*MaskedEmail {
+	active := make([]*MaskedEmail, 0, len(g.MaskedEmails))
+	for _, maskedEmail := range g.MaskedEmails {
+		if maskedEmail.IsActive() {
+			active = append(active, maskedEmail)
+		}
+	}
+
+	return active
+}
+
+// HasDuplicates reports whether the service has several active masked emails.
+func (g *ServiceGroup) HasDuplicates() bool {
+	return g.Domain != "" && len(g.Active()) > 1
+}
+
+type MonthCount struct {
+	Month time.Time
+	Count int
+}
+
+type ServiceCount struct {
+	Domain string
+	Count  int
+}
+
+// Stats summarises all masked emails of a user along with changes made through the bot.
+type Stats struct {
+	States          map[MaskedEmailState]int
+	CreatedPerMonth []*MonthCount
+	TopServices     []*ServiceCount
+	RecentTraffic   int
+	ExpiredUnused   int
+	Events          map[MaskedEmailEventType]int
+}
+
+// MaskedEmailCreate lists properties of a new masked email, empty ones are left to Fastmail.
+type MaskedEmailCreate struct {
+	State       MaskedEmailState
+	ForDomain   string
+	Description string
+	URL         string
+	EmailPrefix string
+}
+
+// MaskedEmailRequest is a masked email asked for with explicit options, empty ones are left out.
+type MaskedEmailRequest struct {
+	// ForDomain is a link or a bare domain of the site
+	ForDomain   string
+	Prefix      string
+	Description string
+	State       MaskedEmailState
+	Labels      []string
+}
+
+// MaskedEmailUpdate lists properties to change, empty state and nil description are left as is.
+type MaskedEmailUpdate struct {
+	State       MaskedEmailState
+	Description *string
+}
+
+type SelectionItem struct {
+	ID       string
+	Label    string
+	Selected bool
+}
+
+// Selection is the state of a multi-select keyboard attached to a bot message.
+type Selection struct {
+	ChatID     int64
+	MessageID  int
+	TelegramID int64
+	// Kind tells which actions the keyboard offers
+	Kind      string
+	Items     []*SelectionItem
+	Page      int
+	CreatedAt time.Time
+}
+
+// SelectedIDs returns IDs of the selected items in their order.
+func (s *Selection) SelectedIDs() []string {
+	ids := make([]string, 0, len(s.Items))
+	for _, item := range s.Items {
+		if item.Selected {
+			ids = append(ids, item.ID)
+		}
+	}
+
+	return ids
+}
+
+// UndoItem is the prior state of a masked email changed by an undoable operation.
+type UndoItem struct {
+	MaskedEmailID string
+	State         MaskedEmailState
+	Description   string
+}
+
+// Undo journals prior states of masked emails changed by one operation.
+// Label is set when the operation labelled masked emails, undoing it removes the label from the items.
+type Undo struct {
+	ID         int64
+	TelegramID int64
+	Label      string
+	Items      []*UndoItem
+	CreatedAt  time.Time
+}
+
+// MaskedEmailFilter narrows masked email lists, empty States means every state but deleted.
+type MaskedEmailFilter struct {
+	States []MaskedEmailState
+	Label  string
+}
+
+// Callback is the action behind a Telegram button, TelegramID is zero when anyone in the chat may press it.
+type Callback struct {
+	Token      string
+	Action     string
+	Args       []string
+	TelegramID int64
+	ExpiresAt  time.Time
+}
+
+// MaskedEmailMessage binds a bot message to the masked email it shows, so replies to the message can act on it.
+type MaskedEmailMessage struct {
+	ChatID        int64
+	MessageID     int
+	TelegramID    int64
+	MaskedEmailID string
+	Email         string
+	CreatedAt     time.Time
+}
+
+// Conversation is a dialog in progress with a user in a chat, their next message goes to the current step.
+type Conversation struct {
+	ChatID     int64
+	TelegramID int64
+	Step       string
+	Data       map[string]string
+	// PromptMessageID is the message asking for the input of the step, in groups only replies to it continue the dialog
+	PromptMessageID int
+	ExpiresAt       time.Time
 }
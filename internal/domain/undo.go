@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// updateMaskedEmails applies the updates and journals prior states of the updated masked emails,
+// so the change can be undone within the undo window.
+func (s *service) updateMaskedEmails(
+	ctx context.Context,
+	tokenSrc oauth2.TokenSource,
+	telegramID int64,
+	updates map[string]*MaskedEmailUpdate,
+	priors map[string]*MaskedEmail,
+) (*BatchResult, error) {
+	result, err := s.email.UpdateMaskedEmails(ctx, tokenSrc, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	undo := &Undo{
+		TelegramID: telegramID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	for _, id := range result.Succeeded {
+		prior, ok := priors[id]
+		if !ok {
+			continue
+		}
+
+		undo.Items = append(undo.Items, &UndoItem{
+			MaskedEmailID: id,
+			State:         prior.State,
+			Description:   prior.Description,
+		})
+	}
+
+	if len(undo.Items) > 0 {
+		result.UndoID = s.journalUndo(undo)
+	}
+
+	return result, nil
+}
+
+// journalUndo saves the undo and returns its ID, zero when it couldn't be saved.
+func (s *service) journalUndo(undo *Undo) int64 {
+	// Journals outlive the window only until the next undoable change
+	if err := s.db.DeleteUndosCreatedBefore(undo.CreatedAt.Add(-s.config.UndoWindow)); err != nil {
+		s.logger.Error("Error while purging old undos!", zap.Error(err))
+	}
+
+	// The change itself is already done, so a journal failure only costs the undo
+	id, err := s.db.CreateUndo(undo)
+	if err != nil {
+		s.logger.Error("Error while journaling an undo!", zap.Error(err))
+		return 0
+	}
+
+	return id
+}
+
+func (s *service) Undo(telegramID int64, undoID int64) (*BatchResult, error) {
+	undo, err := s.db.GetUndo(undoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if undo.TelegramID != telegramID {
+		return nil, ErrNoUndo
+	}
+
+	if time.Since(undo.CreatedAt) > s.config.UndoWindow {
+		if err := s.db.DeleteUndo(undoID); err != nil {
+			s.logger.Error("Error while deleting an expired undo!", zap.Error(err))
+		}
+		return nil, ErrUndoExpired
+	}
+
+	if undo.Label != "" {
+		return s.undoLabel(undo)
+	}
+
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]*MaskedEmailUpdate, len(undo.Items))
+	states := make(map[string]MaskedEmailState, len(undo.Items))
+	for _, item := range undo.Items {
+		state := item.State
+		// Fastmail doesn't let addresses go back to pending, enabling keeps them receiving mail
+		if state == MaskedEmailStatePending {
+			state = MaskedEmailStateEnabled
+		}
+
+		description := item.Description
+		updates[item.MaskedEmailID] = &MaskedEmailUpdate{
+			State:       state,
+			Description: &description,
+		}
+		states[item.MaskedEmailID] = state
+	}
+
+	result, err := s.email.UpdateMaskedEmails(ctx, tokenSrc, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.DeleteUndo(undoID); err != nil {
+		return nil, err
+	}
+
+	for _, id := range result.Succeeded {
+		state := states[id]
+		s.recordEvent(telegramID, id, stateEvents[state], "")
+		s.notifyForumTopic(telegramID, id, stateTopicMessageIDs[state])
+	}
+
+	return result, nil
+}
+
+func (s *service) undoLabel(undo *Undo) (*BatchResult, error) {
+	ids := make([]string, 0, len(undo.Items))
+	for _, item := range undo.Items {
+		ids = append(ids, item.MaskedEmailID)
+	}
+
+	if err := s.db.RemoveMaskedEmailsLabel(undo.TelegramID, ids, undo.Label); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.DeleteUndo(undo.ID); err != nil {
+		return nil, err
+	}
+
+	return &BatchResult{Succeeded: ids}, nil
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// unsubscribeGracePeriod is how long a sender is given to process an unsubscribe request.
+const unsubscribeGracePeriod = 48 * time.Hour
+
+// unsubscribeURLs picks the first HTTPS and mailto URIs from the List-Unsubscribe header.
+func unsubscribeURLs(listUnsubscribe []string) (*url.URL, *url.URL) {
+	var oneClick, mailto *url.URL
+	for _, raw := range listUnsubscribe {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			continue
+		}
+
+		switch strings.ToLower(u.Scheme) {
+		case "https":
+			if oneClick == nil {
+				oneClick = u
+			}
+		case "mailto":
+			if mailto == nil {
+				mailto = u
+			}
+		}
+	}
+
+	return oneClick, mailto
+}
+
+func (s *service) Unsubscribe(telegramID int64, mailID string) (*Unsubscription, error) {
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.email.GetMail(ctx, tokenSrc, mailID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.From == "" {
+		return nil, ErrInvalidAddress
+	}
+
+	// Lists don't address the subscriber first, the masked email may be any of the recipients
+	maskedEmail, err := s.recipientMaskedEmail(ctx, tokenSrc, m)
+	if err != nil {
+		return nil, err
+	}
+
+	unsubscription := &Unsubscription{
+		TelegramID:  telegramID,
+		MaskedEmail: maskedEmail.Email,
+		Sender:      m.From,
+		RequestedAt: time.Now().UTC(),
+		Status:      UnsubscriptionStatusPending,
+	}
+
+	oneClick, mailto := unsubscribeURLs(m.ListUnsubscribe)
+	switch {
+	// RFC 8058 requires both headers to be present for a one-click unsubscribe
+	case oneClick != nil && strings.EqualFold(m.ListUnsubscribePost, "List-Unsubscribe=One-Click"):
+		if err := s.unsubscriber.OneClickUnsubscribe(ctx, oneClick); err != nil {
+			return nil, err
+		}
+		unsubscription.Method = UnsubscribeMethodOneClick
+	case mailto != nil:
+		recipients, err := url.PathUnescape(mailto.Opaque)
+		if err != nil {
+			return nil, ErrInvalidAddress
+		}
+
+		recipient, _, _ := strings.Cut(recipients, ",")
+		to, err := parseAddress(recipient)
+		if err != nil {
+			return nil, err
+		}
+
+		subject := mailto.Query().Get("subject")
+		if subject == "" {
+			subject = "unsubscribe"
+		}
+
+		if err := s.email.SendMail(ctx, tokenSrc, &OutgoingMail{
+			From:    maskedEmail.Email,
+			To:      to,
+			Subject: subject,
+			Body:    mailto.Query().Get("body"),
+		}); err != nil {
+			return nil, err
+		}
+		unsubscription.Method = UnsubscribeMethodMailto
+	default:
+		return nil, ErrNoUnsubscribe
+	}
+
+	if err := s.db.SaveUnsubscription(unsubscription); err != nil {
+		return nil, err
+	}
+
+	return unsubscription, nil
+}
+
+func (s *service) Unsubscriptions(telegramID int64) ([]*Unsubscription, error) {
+	unsubscriptions, err := s.db.GetUnsubscriptions(telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(unsubscriptions) == 0 {
+		return unsubscriptions, nil
+	}
+
+	ctx := context.Background()
+	tokenSrc, err := s.tokenSource(ctx, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A sender honoured the request if nothing has arrived from it after the grace period
+	for _, unsubscription := range unsubscriptions {
+		deadline := unsubscription.RequestedAt.Add(unsubscribeGracePeriod)
+		if time.Now().Before(deadline) {
+			unsubscription.Status = UnsubscriptionStatusPending
+			continue
+		}
+
+		count, err := s.email.CountMails(ctx, tokenSrc, &MailFilter{
+			From:  unsubscription.Sender,
+			To:    unsubscription.MaskedEmail,
+			After: deadline,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		unsubscription.Status = UnsubscriptionStatusHonoured
+		if count > 0 {
+			unsubscription.Status = UnsubscriptionStatusIgnored
+		}
+	}
+
+	return unsubscriptions, nil
+}
@@ -0,0 +1,78 @@
+package breaches
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+// breach is an entry of the Have I Been Pwned breaches dataset.
+type breach struct {
+	Name       string `json:"Name"`
+	Title      string `json:"Title"`
+	Domain     string `json:"Domain"`
+	BreachDate string `json:"BreachDate"`
+}
+
+type adapter struct {
+	logger *zap.Logger
+	config *Config
+}
+
+func NewAdapter(logger *zap.Logger, config *Config) domain.Breaches {
+	return &adapter{
+		logger: logger,
+		config: config,
+	}
+}
+
+// GetBreaches reads the dataset on every call, so a periodically exported file is picked up without restarts.
+func (a *adapter) GetBreaches() ([]*domain.Breach, error) {
+	if a.config.Path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(a.config.Path)
+	if err != nil {
+		a.logger.Error("Error while opening breaches dataset!", zap.Error(err))
+		return nil, domain.ErrBreachesInternal
+	}
+	defer f.Close()
+
+	var entries []*breach
+	if err := json.NewDecoder(f).Decode(&entries); err != nil {
+		a.logger.Error("Error while trying to decode breaches dataset!", zap.Error(err))
+		return nil, domain.ErrBreachesInternal
+	}
+
+	breaches := make([]*domain.Breach, 0, len(entries))
+	for _, entry := range entries {
+		// Breaches of unknown origin can't be matched to any masked email
+		if entry.Name == "" || entry.Domain == "" {
+			continue
+		}
+
+		title := entry.Title
+		if title == "" {
+			title = entry.Name
+		}
+
+		result := &domain.Breach{
+			Name:   entry.Name,
+			Title:  title,
+			Domain: strings.ToLower(entry.Domain),
+		}
+
+		if breachDate, err := time.Parse(time.DateOnly, entry.BreachDate); err == nil {
+			result.BreachDate = breachDate
+		}
+
+		breaches = append(breaches, result)
+	}
+
+	return breaches, nil
+}
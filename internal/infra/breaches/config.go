@@ -0,0 +1,6 @@
+package breaches
+
+type Config struct {
+	// Path to a JSON export of breaches in the Have I Been Pwned format, checks are off when empty
+	Path string `env:"BREACHES_PATH"`
+}
@@ -5,10 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"net/netip"
 	"net/url"
-	"regexp"
-	"strings"
+	"sort"
+	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
@@ -29,6 +29,11 @@ func NewAdapter(logger *zap.Logger, config *Config) domain.MaskingEmail {
 }
 
 func (a *adapter) openSession(ctx context.Context, tokenSrc oauth2.TokenSource) (string, error) {
+	return a.openSessionFor(ctx, tokenSrc, CapabilityMaskedEmail)
+}
+
+// openSessionFor returns the primary account ID for the given JMAP capability.
+func (a *adapter) openSessionFor(ctx context.Context, tokenSrc oauth2.TokenSource, capability string) (string, error) {
 	req, err := http.NewRequest(http.MethodGet, "https://api.fastmail.com/jmap/session", nil)
 	if err != nil {
 		a.logger.Error("Error while creating a new HTTP request!", zap.Error(err))
@@ -56,153 +61,260 @@ func (a *adapter) openSession(ctx context.Context, tokenSrc oauth2.TokenSource)
 	}
 
 	for k, v := range jsonResp.PrimaryAccounts {
-		if k == "https://www.fastmail.com/dev/maskedemail" {
+		if k == capability {
 			return v, nil
 		}
 	}
 
+	// The session lists only capabilities the token has been granted, tokens issued before a scope was added lack it
+	if len(jsonResp.PrimaryAccounts) > 0 {
+		a.logger.Error("Token lacks the scope of the capability!", zap.String("capability", capability))
+		return "", domain.ErrFastmailInsufficientScope
+	}
+
 	return "", domain.ErrFastmailPrimaryAccountNotFound
 }
 
-func (a *adapter) createMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, accountID, forDomain, emailPrefix string) (*MaskedEmail, error) {
-	request := &Request[*MaskedEmailSetRequest]{
-		Using: []string{
-			"urn:ietf:params:jmap:core",
-			"https://www.fastmail.com/dev/maskedemail",
-		},
-		MethodCalls: []*Invocation[*MaskedEmailSetRequest]{
-			{
-				Name: "MaskedEmail/set",
-				Body: &MaskedEmailSetRequest{
-					AccountID: accountID,
-					Create: map[string]*MaskedEmail{
-						"k1": {
-							ForDomain:   forDomain,
-							EmailPrefix: emailPrefix,
-						},
-					},
-				},
-				ID: "0",
-			},
-		},
-	}
-
+// call sends a JMAP request to the API endpoint and decodes the response into the given value.
+func (a *adapter) call(ctx context.Context, tokenSrc oauth2.TokenSource, request, response any) error {
 	buf := bytes.NewBuffer(nil)
 	if err := json.NewEncoder(buf).Encode(request); err != nil {
 		a.logger.Error("Error while trying to encode JSON request!", zap.Error(err))
-		return nil, domain.ErrFastmailInternal
+		return domain.ErrFastmailInternal
 	}
 
 	req, err := http.NewRequest(http.MethodPost, "https://api.fastmail.com/jmap/api/", buf)
 	if err != nil {
 		a.logger.Error("Error while creating a new HTTP request!", zap.Error(err))
-		return nil, domain.ErrFastmailInternal
+		return domain.ErrFastmailInternal
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := oauth2.NewClient(ctx, tokenSrc).Do(req)
 	if err != nil {
 		a.logger.Error("Error while doing an HTTP request!", zap.Error(err))
-		return nil, domain.ErrFastmailInternal
+		return domain.ErrFastmailInternal
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		a.logger.Error("Wrong status code!")
-		return nil, domain.ErrFastmailInternal
+	if resp.StatusCode == http.StatusForbidden {
+		a.logger.Error("Token lacks the scope of the request!", zap.Int("status_code", resp.StatusCode))
+		return domain.ErrFastmailInsufficientScope
 	}
 
-	var jsonResp struct {
-		MethodResponses []*Invocation[*MaskedEmailSetResponse] `json:"methodResponses"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&jsonResp); err != nil {
-		a.logger.Error("Error while trying to decode JSON response!", zap.Error(err))
-		return nil, domain.ErrFastmailInternal
+	if resp.StatusCode != http.StatusOK {
+		// Capabilities the token hasn't been granted are rejected as unknown
+		var problem struct {
+			Type string `json:"type"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err == nil && problem.Type == ProblemUnknownCapability {
+			a.logger.Error("Token lacks the scope of the request!", zap.Int("status_code", resp.StatusCode))
+			return domain.ErrFastmailInsufficientScope
+		}
+
+		a.logger.Error("Wrong status code!", zap.Int("status_code", resp.StatusCode))
+		return domain.ErrFastmailInternal
 	}
 
-	created, ok := jsonResp.MethodResponses[0].Body.Created["k1"]
-	if !ok {
-		return nil, domain.ErrFastmailInternal
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		a.logger.Error("Error while trying to decode JSON response!", zap.Error(err))
+		return domain.ErrFastmailInternal
 	}
 
-	return created, nil
+	return nil
 }
 
-func (a *adapter) CreateMaskedEmailFromURL(ctx context.Context, tokenSrc oauth2.TokenSource, u *url.URL) (*domain.MaskedEmail, error) {
-	u.Opaque = ""
-	u.User = nil
-	u.Path = ""
-	u.RawQuery = ""
-	u.Fragment = ""
+// decodeMethodResponse finds the method response with the given call ID and decodes its arguments.
+func (a *adapter) decodeMethodResponse(responses []*Invocation[json.RawMessage], id string, body any) error {
+	for _, r := range responses {
+		if r.ID != id {
+			continue
+		}
+
+		if r.Name == "error" {
+			var methodErr MethodError
+			if err := json.Unmarshal(r.Body, &methodErr); err != nil {
+				a.logger.Error("Error while trying to decode JSON response!", zap.Error(err))
+				return domain.ErrFastmailInternal
+			}
+
+			a.logger.Error(
+				"JMAP method call failed!",
+				zap.String("type", methodErr.Type),
+				zap.String("description", methodErr.Description),
+			)
+			if methodErr.Type == MethodErrorForbidden {
+				return domain.ErrFastmailInsufficientScope
+			}
+			return domain.ErrFastmailInternal
+		}
+
+		if err := json.Unmarshal(r.Body, body); err != nil {
+			a.logger.Error("Error while trying to decode JSON response!", zap.Error(err))
+			return domain.ErrFastmailInternal
+		}
+
+		return nil
+	}
 
-	emailPrefix := ""
+	a.logger.Error("JMAP method response not found!", zap.String("id", id))
+	return domain.ErrFastmailInternal
+}
 
-	if _, err := netip.ParseAddr(u.Hostname()); err == nil {
-		emailPrefix = "ipaddr"
+func (a *adapter) createMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, accountID string, maskedEmail *MaskedEmail) (*MaskedEmail, error) {
+	request := &Request[*MaskedEmailSetRequest]{
+		Using: []string{
+			CapabilityCore,
+			CapabilityMaskedEmail,
+		},
+		MethodCalls: []*Invocation[*MaskedEmailSetRequest]{
+			{
+				Name: "MaskedEmail/set",
+				Body: &MaskedEmailSetRequest{
+					AccountID: accountID,
+					Create: map[string]*MaskedEmail{
+						"k1": maskedEmail,
+					},
+				},
+				ID: "0",
+			},
+		},
 	}
 
-	parts := strings.Split(u.Hostname(), ".")
-	switch len(parts) {
-	case 1:
-		emailPrefix = parts[0]
-	case 2:
-		emailPrefix = parts[0]
-	default:
-		emailPrefix = parts[len(parts)-2]
+	var jsonResp Response[*MaskedEmailSetResponse]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return nil, err
 	}
 
-	switch emailPrefix {
-	case "fastmail":
-		emailPrefix = "mail"
-	case "github":
-		emailPrefix = "dev"
+	created, ok := jsonResp.MethodResponses[0].Body.Created["k1"]
+	if !ok {
+		return nil, domain.ErrFastmailInternal
 	}
 
-	// remove all special characters except underscore
-	emailPrefix = regexp.MustCompile(`[^a-zA-Z0-9_]+`).ReplaceAllString(emailPrefix, "")
+	return created, nil
+}
 
+// CreateMaskedEmail creates a masked email with the given properties, the ones left empty are set by Fastmail.
+func (a *adapter) CreateMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, create *domain.MaskedEmailCreate) (*domain.MaskedEmail, error) {
 	accountId, err := a.openSession(ctx, tokenSrc)
 	if err != nil {
 		return nil, err
 	}
 
-	maskedEmail, err := a.createMaskedEmail(ctx, tokenSrc, accountId, u.String(), emailPrefix)
+	request := &MaskedEmail{
+		State:       MaskedEmailState(create.State),
+		ForDomain:   create.ForDomain,
+		Description: create.Description,
+		EmailPrefix: create.EmailPrefix,
+	}
+	if create.URL != "" {
+		request.URL = &create.URL
+	}
+
+	created, err := a.createMaskedEmail(ctx, tokenSrc, accountId, request)
 	if err != nil {
 		return nil, err
 	}
 
-	return &domain.MaskedEmail{
-		ID:    maskedEmail.ID,
-		Email: maskedEmail.Email,
-	}, nil
+	// Only server-set properties come back, the requested ones are filled in
+	maskedEmail := toDomainMaskedEmail(created)
+	if maskedEmail.State == "" {
+		maskedEmail.State = create.State
+	}
+	if maskedEmail.ForDomain == "" {
+		maskedEmail.ForDomain = create.ForDomain
+	}
+	if maskedEmail.Description == "" {
+		maskedEmail.Description = create.Description
+	}
+	if maskedEmail.URL == "" {
+		maskedEmail.URL = create.URL
+	}
+	if maskedEmail.EmailPrefix == "" {
+		maskedEmail.EmailPrefix = create.EmailPrefix
+	}
+
+	return maskedEmail, nil
 }
 
-func (a *adapter) CreateMaskedEmailWithPrefix(ctx context.Context, tokenSrc oauth2.TokenSource, prefix string) (*domain.MaskedEmail, error) {
-	accountId, err := a.openSession(ctx, tokenSrc)
+// CreateMaskedEmailsFromURLs creates a masked email per URL in one MaskedEmail/set call,
+// the result is aligned with the URLs and holds nil for the ones that weren't created.
+func (a *adapter) CreateMaskedEmailsFromURLs(ctx context.Context, tokenSrc oauth2.TokenSource, urls []*url.URL) ([]*domain.MaskedEmail, error) {
+	accountID, err := a.openSession(ctx, tokenSrc)
 	if err != nil {
 		return nil, err
 	}
 
-	maskedEmail, err := a.createMaskedEmail(ctx, tokenSrc, accountId, "", prefix)
-	if err != nil {
+	// Imported logins rarely send mail within a day, a pending address would be deleted before they do
+	create := make(map[string]*MaskedEmail, len(urls))
+	for i, u := range urls {
+		u = domain.SiteOrigin(u)
+		create["k"+strconv.Itoa(i)] = &MaskedEmail{
+			State:       MaskedEmailStateEnabled,
+			ForDomain:   u.String(),
+			EmailPrefix: domain.SiteEmailPrefix(u),
+		}
+	}
+
+	request := &Request[*MaskedEmailSetRequest]{
+		Using: []string{CapabilityCore, CapabilityMaskedEmail},
+		MethodCalls: []*Invocation[*MaskedEmailSetRequest]{
+			{
+				Name: "MaskedEmail/set",
+				Body: &MaskedEmailSetRequest{
+					AccountID: accountID,
+					Create:    create,
+				},
+				ID: "0",
+			},
+		},
+	}
+
+	var jsonResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return nil, err
+	}
+
+	var setResp MaskedEmailSetResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "0", &setResp); err != nil {
 		return nil, err
 	}
 
-	return &domain.MaskedEmail{
-		ID:    maskedEmail.ID,
-		Email: maskedEmail.Email,
-	}, nil
+	result := make([]*domain.MaskedEmail, len(urls))
+	for i := range urls {
+		key := "k" + strconv.Itoa(i)
+		if created, ok := setResp.Created[key]; ok {
+			maskedEmail := toDomainMaskedEmail(created)
+			// Only server-set properties come back, the requested ones are filled in
+			if maskedEmail.ForDomain == "" {
+				maskedEmail.ForDomain = create[key].ForDomain
+			}
+			result[i] = maskedEmail
+			continue
+		}
+
+		if setErr, ok := setResp.NotCreated[key]; ok {
+			a.logger.Warn(
+				"Masked email wasn't created!",
+				zap.String("for_domain", create[key].ForDomain),
+				zap.String("type", setErr.Type),
+				zap.String("description", setErr.Description),
+			)
+		}
+	}
+
+	return result, nil
 }
 
 func (a *adapter) enableMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, accountID, id string) error {
 	request := &Request[*MaskedEmailSetRequest]{
-		Using: []string{"https://www.fastmail.com/dev/maskedemail"},
+		Using: []string{CapabilityMaskedEmail},
 		MethodCalls: []*Invocation[*MaskedEmailSetRequest]{
 			{
 				Name: "MaskedEmail/set",
 				Body: &MaskedEmailSetRequest{
 					AccountID: accountID,
-					Update: map[string]*MaskedEmail{
+					Update: map[string]*MaskedEmailPatch{
 						id: {
 							State: MaskedEmailStateEnabled,
 						},
@@ -213,51 +325,182 @@ func (a *adapter) enableMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSo
 		},
 	}
 
-	buf := bytes.NewBuffer(nil)
-	if err := json.NewEncoder(buf).Encode(request); err != nil {
-		a.logger.Error("Error while trying to encode JSON request!", zap.Error(err))
-		return domain.ErrFastmailInternal
+	var jsonResp Response[*MaskedEmailSetResponse]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, "https://api.fastmail.com/jmap/api/", buf)
+	return nil
+}
+
+func (a *adapter) EnableMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, id string) error {
+	accountId, err := a.openSession(ctx, tokenSrc)
 	if err != nil {
-		a.logger.Error("Error while creating a new HTTP request!", zap.Error(err))
-		return domain.ErrFastmailInternal
+		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := oauth2.NewClient(ctx, tokenSrc).Do(req)
+	if err := a.enableMaskedEmail(ctx, tokenSrc, accountId, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// createdAtLayout is the format Fastmail uses for the createdAt property.
+const createdAtLayout = "2006-01-02 15:04:05"
+
+func toDomainMaskedEmail(maskedEmail *MaskedEmail) *domain.MaskedEmail {
+	result := &domain.MaskedEmail{
+		ID:          maskedEmail.ID,
+		Email:       maskedEmail.Email,
+		State:       domain.MaskedEmailState(maskedEmail.State),
+		ForDomain:   maskedEmail.ForDomain,
+		Description: maskedEmail.Description,
+		EmailPrefix: maskedEmail.EmailPrefix,
+		CreatedBy:   maskedEmail.CreatedBy,
+	}
+
+	if maskedEmail.URL != nil {
+		result.URL = *maskedEmail.URL
+	}
+
+	if maskedEmail.LastMessageAt != nil {
+		result.LastMessageAt = *maskedEmail.LastMessageAt
+	}
+
+	if createdAt, err := time.Parse(time.RFC3339, maskedEmail.CreatedAt); err == nil {
+		result.CreatedAt = createdAt
+	} else if createdAt, err := time.Parse(createdAtLayout, maskedEmail.CreatedAt); err == nil {
+		result.CreatedAt = createdAt
+	}
+
+	return result
+}
+
+// getMaskedEmails fetches masked emails by IDs, nil IDs fetch every masked email of the account.
+func (a *adapter) getMaskedEmails(ctx context.Context, tokenSrc oauth2.TokenSource, ids []string) ([]*domain.MaskedEmail, error) {
+	accountID, err := a.openSession(ctx, tokenSrc)
 	if err != nil {
-		a.logger.Error("Error while doing an HTTP request!", zap.Error(err))
-		return domain.ErrFastmailInternal
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		a.logger.Error("Wrong status code!")
-		return domain.ErrFastmailInternal
+	request := &Request[*MaskedEmailGetRequest]{
+		Using: []string{CapabilityCore, CapabilityMaskedEmail},
+		MethodCalls: []*Invocation[*MaskedEmailGetRequest]{
+			{
+				Name: "MaskedEmail/get",
+				Body: &MaskedEmailGetRequest{
+					AccountID: accountID,
+					IDs:       ids,
+				},
+				ID: "0",
+			},
+		},
 	}
 
-	var jsonResp struct {
-		MethodResponses []*Invocation[*MaskedEmailSetResponse] `json:"methodResponses"`
+	var jsonResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return nil, err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&jsonResp); err != nil {
-		a.logger.Error("Error while trying to decode JSON response!", zap.Error(err))
-		return domain.ErrFastmailInternal
+
+	var getResp MaskedEmailGetResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "0", &getResp); err != nil {
+		return nil, err
 	}
 
-	return nil
+	maskedEmails := make([]*domain.MaskedEmail, 0, len(getResp.List))
+	for _, maskedEmail := range getResp.List {
+		maskedEmails = append(maskedEmails, toDomainMaskedEmail(maskedEmail))
+	}
+
+	return maskedEmails, nil
 }
 
-func (a *adapter) EnableMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, id string) error {
-	accountId, err := a.openSession(ctx, tokenSrc)
+func (a *adapter) GetMaskedEmails(ctx context.Context, tokenSrc oauth2.TokenSource) ([]*domain.MaskedEmail, error) {
+	return a.getMaskedEmails(ctx, tokenSrc, nil)
+}
+
+func (a *adapter) GetMaskedEmail(ctx context.Context, tokenSrc oauth2.TokenSource, id string) (*domain.MaskedEmail, error) {
+	maskedEmails, err := a.getMaskedEmails(ctx, tokenSrc, []string{id})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := a.enableMaskedEmail(ctx, tokenSrc, accountId, id); err != nil {
-		return err
+	if len(maskedEmails) == 0 {
+		return nil, domain.ErrNoMaskedEmail
 	}
 
-	return nil
+	return maskedEmails[0], nil
+}
+
+func (a *adapter) SetMaskedEmailStates(ctx context.Context, tokenSrc oauth2.TokenSource, states map[string]domain.MaskedEmailState) (*domain.BatchResult, error) {
+	updates := make(map[string]*domain.MaskedEmailUpdate, len(states))
+	for id, state := range states {
+		updates[id] = &domain.MaskedEmailUpdate{State: state}
+	}
+
+	return a.UpdateMaskedEmails(ctx, tokenSrc, updates)
+}
+
+// UpdateMaskedEmails changes masked emails in one MaskedEmail/set call and reports which of them were updated.
+func (a *adapter) UpdateMaskedEmails(ctx context.Context, tokenSrc oauth2.TokenSource, updates map[string]*domain.MaskedEmailUpdate) (*domain.BatchResult, error) {
+	accountID, err := a.openSession(ctx, tokenSrc)
+	if err != nil {
+		return nil, err
+	}
+
+	update := make(map[string]*MaskedEmailPatch, len(updates))
+	for id, u := range updates {
+		update[id] = &MaskedEmailPatch{
+			State:       MaskedEmailState(u.State),
+			Description: u.Description,
+		}
+	}
+
+	request := &Request[*MaskedEmailSetRequest]{
+		Using: []string{CapabilityCore, CapabilityMaskedEmail},
+		MethodCalls: []*Invocation[*MaskedEmailSetRequest]{
+			{
+				Name: "MaskedEmail/set",
+				Body: &MaskedEmailSetRequest{
+					AccountID: accountID,
+					Update:    update,
+				},
+				ID: "0",
+			},
+		},
+	}
+
+	var jsonResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return nil, err
+	}
+
+	var setResp MaskedEmailSetResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "0", &setResp); err != nil {
+		return nil, err
+	}
+
+	result := &domain.BatchResult{}
+	for id := range updates {
+		if _, ok := setResp.Updated[id]; ok {
+			result.Succeeded = append(result.Succeeded, id)
+			continue
+		}
+
+		if setErr, ok := setResp.NotUpdated[id]; ok {
+			a.logger.Warn(
+				"Masked email has not been updated!",
+				zap.String("id", id),
+				zap.String("type", setErr.Type),
+				zap.String("description", setErr.Description),
+			)
+		}
+		result.Failed = append(result.Failed, id)
+	}
+
+	sort.Strings(result.Succeeded)
+	sort.Strings(result.Failed)
+
+	return result, nil
 }
@@ -0,0 +1,437 @@
+package fastmail
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+)
+
+var mailProperties = []string{
+	"id",
+	"messageId",
+	"references",
+	"from",
+	"to",
+	"cc",
+	"subject",
+	"receivedAt",
+	"preview",
+	"header:List-Unsubscribe:asURLs",
+	"header:List-Unsubscribe-Post:asText",
+}
+
+func toDomainMail(email *Email) *domain.Mail {
+	mail := &domain.Mail{
+		ID:         email.ID,
+		Subject:    email.Subject,
+		Preview:    email.Preview,
+		MessageID:  email.MessageID,
+		References: email.References,
+
+		ListUnsubscribe:     email.ListUnsubscribe,
+		ListUnsubscribePost: strings.TrimSpace(email.ListUnsubscribePost),
+	}
+
+	if len(email.From) > 0 {
+		mail.From = email.From[0].Email
+	}
+
+	for _, to := range email.To {
+		mail.To = append(mail.To, to.Email)
+	}
+
+	for _, cc := range email.Cc {
+		mail.Cc = append(mail.Cc, cc.Email)
+	}
+
+	if email.ReceivedAt != nil {
+		mail.ReceivedAt = *email.ReceivedAt
+	}
+
+	return mail
+}
+
+func toEmailFilter(filter *domain.MailFilter) *EmailFilter {
+	emailFilter := &EmailFilter{
+		From: filter.From,
+		To:   filter.To,
+	}
+
+	if !filter.After.IsZero() {
+		after := filter.After.UTC()
+		emailFilter.After = &after
+	}
+
+	if !filter.Before.IsZero() {
+		before := filter.Before.UTC()
+		emailFilter.Before = &before
+	}
+
+	return emailFilter
+}
+
+func (a *adapter) GetMails(ctx context.Context, tokenSrc oauth2.TokenSource, filter *domain.MailFilter, limit int) ([]*domain.Mail, error) {
+	accountID, err := a.openSessionFor(ctx, tokenSrc, CapabilityMail)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &Request[any]{
+		Using: []string{CapabilityCore, CapabilityMail},
+		MethodCalls: []*Invocation[any]{
+			{
+				Name: "Email/query",
+				Body: &EmailQueryRequest{
+					AccountID: accountID,
+					Filter:    toEmailFilter(filter),
+					Sort:      []*Comparator{{Property: "receivedAt", IsAscending: filter.OldestFirst}},
+					Limit:     limit,
+				},
+				ID: "0",
+			},
+			{
+				Name: "Email/get",
+				Body: &EmailGetRequest{
+					AccountID: accountID,
+					IDsRef: &ResultReference{
+						ResultOf: "0",
+						Name:     "Email/query",
+						Path:     "/ids",
+					},
+					Properties: mailProperties,
+				},
+				ID: "1",
+			},
+		},
+	}
+
+	var jsonResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return nil, err
+	}
+
+	var getResp EmailGetResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "1", &getResp); err != nil {
+		return nil, err
+	}
+
+	mails := make([]*domain.Mail, 0, len(getResp.List))
+	for _, email := range getResp.List {
+		mails = append(mails, toDomainMail(email))
+	}
+
+	return mails, nil
+}
+
+func (a *adapter) CountMails(ctx context.Context, tokenSrc oauth2.TokenSource, filter *domain.MailFilter) (int, error) {
+	accountID, err := a.openSessionFor(ctx, tokenSrc, CapabilityMail)
+	if err != nil {
+		return 0, err
+	}
+
+	request := &Request[*EmailQueryRequest]{
+		Using: []string{CapabilityCore, CapabilityMail},
+		MethodCalls: []*Invocation[*EmailQueryRequest]{
+			{
+				Name: "Email/query",
+				Body: &EmailQueryRequest{
+					AccountID:      accountID,
+					Filter:         toEmailFilter(filter),
+					Limit:          1,
+					CalculateTotal: true,
+				},
+				ID: "0",
+			},
+		},
+	}
+
+	var jsonResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return 0, err
+	}
+
+	var queryResp EmailQueryResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "0", &queryResp); err != nil {
+		return 0, err
+	}
+
+	return queryResp.Total, nil
+}
+
+func (a *adapter) GetMail(ctx context.Context, tokenSrc oauth2.TokenSource, id string) (*domain.Mail, error) {
+	accountID, err := a.openSessionFor(ctx, tokenSrc, CapabilityMail)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &Request[*EmailGetRequest]{
+		Using: []string{CapabilityCore, CapabilityMail},
+		MethodCalls: []*Invocation[*EmailGetRequest]{
+			{
+				Name: "Email/get",
+				Body: &EmailGetRequest{
+					AccountID:  accountID,
+					IDs:        []string{id},
+					Properties: mailProperties,
+				},
+				ID: "0",
+			},
+		},
+	}
+
+	var jsonResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return nil, err
+	}
+
+	var getResp EmailGetResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "0", &getResp); err != nil {
+		return nil, err
+	}
+
+	if len(getResp.List) == 0 {
+		return nil, domain.ErrNoMail
+	}
+
+	return toDomainMail(getResp.List[0]), nil
+}
+
+// getMailboxes returns IDs of the drafts and sent mailboxes.
+func (a *adapter) getMailboxes(ctx context.Context, tokenSrc oauth2.TokenSource, accountID string) (string, string, error) {
+	request := &Request[*MailboxGetRequest]{
+		Using: []string{CapabilityCore, CapabilityMail},
+		MethodCalls: []*Invocation[*MailboxGetRequest]{
+			{
+				Name: "Mailbox/get",
+				Body: &MailboxGetRequest{
+					AccountID:  accountID,
+					Properties: []string{"id", "role"},
+				},
+				ID: "0",
+			},
+		},
+	}
+
+	var jsonResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return "", "", err
+	}
+
+	var getResp MailboxGetResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "0", &getResp); err != nil {
+		return "", "", err
+	}
+
+	var drafts, sent string
+	for _, mailbox := range getResp.List {
+		switch mailbox.Role {
+		case "drafts":
+			drafts = mailbox.ID
+		case "sent":
+			sent = mailbox.ID
+		}
+	}
+
+	if drafts == "" || sent == "" {
+		a.logger.Error("Drafts or sent mailbox not found!")
+		return "", "", domain.ErrFastmailInternal
+	}
+
+	return drafts, sent, nil
+}
+
+// getOrCreateIdentity returns an identity for the given address.
+// A missing identity is created only for a masked email of the account, identities outlive the mail they were created for.
+func (a *adapter) getOrCreateIdentity(ctx context.Context, tokenSrc oauth2.TokenSource, accountID, email string) (string, error) {
+	request := &Request[*IdentityGetRequest]{
+		Using: []string{CapabilityCore, CapabilitySubmission},
+		MethodCalls: []*Invocation[*IdentityGetRequest]{
+			{
+				Name: "Identity/get",
+				Body: &IdentityGetRequest{
+					AccountID: accountID,
+				},
+				ID: "0",
+			},
+		},
+	}
+
+	var jsonResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return "", err
+	}
+
+	var getResp IdentityGetResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "0", &getResp); err != nil {
+		return "", err
+	}
+
+	for _, identity := range getResp.List {
+		if strings.EqualFold(identity.Email, email) {
+			return identity.ID, nil
+		}
+	}
+
+	maskedEmails, err := a.GetMaskedEmails(ctx, tokenSrc)
+	if err != nil {
+		return "", err
+	}
+
+	owned := false
+	for _, maskedEmail := range maskedEmails {
+		if strings.EqualFold(maskedEmail.Email, email) && maskedEmail.State != domain.MaskedEmailStateDeleted {
+			owned = true
+			break
+		}
+	}
+	if !owned {
+		a.logger.Error("Refusing to create an identity for an address that isn't a masked email!")
+		return "", domain.ErrInvalidAddress
+	}
+
+	setRequest := &Request[*IdentitySetRequest]{
+		Using: []string{CapabilityCore, CapabilitySubmission},
+		MethodCalls: []*Invocation[*IdentitySetRequest]{
+			{
+				Name: "Identity/set",
+				Body: &IdentitySetRequest{
+					AccountID: accountID,
+					Create: map[string]*Identity{
+						"identity": {
+							Email: email,
+						},
+					},
+				},
+				ID: "0",
+			},
+		},
+	}
+
+	var setJSONResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, setRequest, &setJSONResp); err != nil {
+		return "", err
+	}
+
+	var setResp IdentitySetResponse
+	if err := a.decodeMethodResponse(setJSONResp.MethodResponses, "0", &setResp); err != nil {
+		return "", err
+	}
+
+	created, ok := setResp.Created["identity"]
+	if !ok {
+		if setErr, ok := setResp.NotCreated["identity"]; ok {
+			a.logger.Error(
+				"Error while creating an identity!",
+				zap.String("type", setErr.Type),
+				zap.String("description", setErr.Description),
+			)
+		}
+		return "", domain.ErrFastmailInternal
+	}
+
+	return created.ID, nil
+}
+
+func (a *adapter) SendMail(ctx context.Context, tokenSrc oauth2.TokenSource, mail *domain.OutgoingMail) error {
+	accountID, err := a.openSessionFor(ctx, tokenSrc, CapabilityMail)
+	if err != nil {
+		return err
+	}
+
+	identityID, err := a.getOrCreateIdentity(ctx, tokenSrc, accountID, mail.From)
+	if err != nil {
+		return err
+	}
+
+	drafts, sent, err := a.getMailboxes(ctx, tokenSrc, accountID)
+	if err != nil {
+		return err
+	}
+
+	request := &Request[any]{
+		Using: []string{CapabilityCore, CapabilityMail, CapabilitySubmission},
+		MethodCalls: []*Invocation[any]{
+			{
+				Name: "Email/set",
+				Body: &EmailSetRequest{
+					AccountID: accountID,
+					Create: map[string]*Email{
+						"draft": {
+							MailboxIDs: map[string]bool{drafts: true},
+							Keywords:   map[string]bool{"$draft": true, "$seen": true},
+							InReplyTo:  mail.InReplyTo,
+							References: mail.References,
+							From:       []*EmailAddress{{Email: mail.From}},
+							To:         []*EmailAddress{{Email: mail.To}},
+							Subject:    mail.Subject,
+							BodyValues: map[string]*EmailBodyValue{
+								"body": {Value: mail.Body},
+							},
+							TextBody: []*EmailBodyPart{{PartID: "body", Type: "text/plain"}},
+						},
+					},
+				},
+				ID: "0",
+			},
+			{
+				Name: "EmailSubmission/set",
+				Body: &EmailSubmissionSetRequest{
+					AccountID: accountID,
+					Create: map[string]*EmailSubmission{
+						"submission": {
+							IdentityID: identityID,
+							EmailID:    "#draft",
+						},
+					},
+					// Move the sent message out of drafts once it has been submitted
+					OnSuccessUpdateEmail: map[string]map[string]any{
+						"#submission": {
+							"mailboxIds/" + drafts: nil,
+							"mailboxIds/" + sent:   true,
+							"keywords/$draft":      nil,
+						},
+					},
+				},
+				ID: "1",
+			},
+		},
+	}
+
+	var jsonResp Response[json.RawMessage]
+	if err := a.call(ctx, tokenSrc, request, &jsonResp); err != nil {
+		return err
+	}
+
+	var emailResp EmailSetResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "0", &emailResp); err != nil {
+		return err
+	}
+	if setErr, ok := emailResp.NotCreated["draft"]; ok {
+		a.logger.Error(
+			"Error while creating an email!",
+			zap.String("type", setErr.Type),
+			zap.String("description", setErr.Description),
+		)
+		return domain.ErrFastmailInternal
+	}
+
+	var submissionResp EmailSubmissionSetResponse
+	if err := a.decodeMethodResponse(jsonResp.MethodResponses, "1", &submissionResp); err != nil {
+		return err
+	}
+	if setErr, ok := submissionResp.NotCreated["submission"]; ok {
+		a.logger.Error(
+			"Error while submitting an email!",
+			zap.String("type", setErr.Type),
+			zap.String("description", setErr.Description),
+		)
+		return domain.ErrFastmailInternal
+	}
+
+	return nil
+}
@@ -6,6 +6,18 @@ import (
 	"time"
 )
 
+const (
+	CapabilityCore        = "urn:ietf:params:jmap:core"
+	CapabilityMail        = "urn:ietf:params:jmap:mail"
+	CapabilitySubmission  = "urn:ietf:params:jmap:submission"
+	CapabilityMaskedEmail = "https://www.fastmail.com/dev/maskedemail"
+)
+
+const (
+	ProblemUnknownCapability = "urn:ietf:params:jmap:error:unknownCapability"
+	MethodErrorForbidden     = "forbidden"
+)
+
 type MaskedEmail struct {
 	ID            string           `json:"id,omitempty"`
 	Email         string           `json:"email,omitempty"`
@@ -19,16 +31,32 @@ type MaskedEmail struct {
 	EmailPrefix   string           `json:"emailPrefix,omitempty"`
 }
 
+// MaskedEmailPatch lists properties to change, a non-nil empty description clears it.
+type MaskedEmailPatch struct {
+	State       MaskedEmailState `json:"state,omitempty"`
+	Description *string          `json:"description,omitempty"`
+}
+
 type Request[T any] struct {
 	Using       []string         `json:"using"`
 	MethodCalls []*Invocation[T] `json:"methodCalls"`
 }
 
+type MaskedEmailGetRequest struct {
+	AccountID string   `json:"accountId"`
+	IDs       []string `json:"ids"`
+}
+
+type MaskedEmailGetResponse struct {
+	List     []*MaskedEmail `json:"list"`
+	NotFound []string       `json:"notFound"`
+}
+
 type MaskedEmailSetRequest struct {
-	AccountID string                  `json:"accountId"`
-	Create    map[string]*MaskedEmail `json:"create,omitempty"`
-	Update    map[string]*MaskedEmail `json:"update,omitempty"`
-	Destroy   []string                `json:"destroy,omitempty"`
+	AccountID string                       `json:"accountId"`
+	Create    map[string]*MaskedEmail      `json:"create,omitempty"`
+	Update    map[string]*MaskedEmailPatch `json:"update,omitempty"`
+	Destroy   []string                     `json:"destroy,omitempty"`
 }
 
 type MaskedEmailState string
@@ -46,9 +74,164 @@ type Response[T any] struct {
 }
 
 type MaskedEmailSetResponse struct {
-	Created   map[string]*MaskedEmail `json:"created"`
-	Updated   map[string]*MaskedEmail `json:"updated"`
-	Destroyed []string                `json:"destroyed"`
+	Created    map[string]*MaskedEmail `json:"created"`
+	Updated    map[string]*MaskedEmail `json:"updated"`
+	Destroyed  []string                `json:"destroyed"`
+	NotCreated map[string]*SetError    `json:"notCreated"`
+	NotUpdated map[string]*SetError    `json:"notUpdated"`
+}
+
+type MethodError struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+type SetError struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// ResultReference points to the result of a previous method call in the same request.
+type ResultReference struct {
+	ResultOf string `json:"resultOf"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+}
+
+type Comparator struct {
+	Property    string `json:"property"`
+	IsAscending bool   `json:"isAscending"`
+}
+
+type EmailAddress struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+type EmailBodyPart struct {
+	PartID string `json:"partId,omitempty"`
+	Type   string `json:"type,omitempty"`
+}
+
+type EmailBodyValue struct {
+	Value string `json:"value"`
+}
+
+type Email struct {
+	ID         string                     `json:"id,omitempty"`
+	MailboxIDs map[string]bool            `json:"mailboxIds,omitempty"`
+	Keywords   map[string]bool            `json:"keywords,omitempty"`
+	MessageID  []string                   `json:"messageId,omitempty"`
+	InReplyTo  []string                   `json:"inReplyTo,omitempty"`
+	References []string                   `json:"references,omitempty"`
+	From       []*EmailAddress            `json:"from,omitempty"`
+	To         []*EmailAddress            `json:"to,omitempty"`
+	Cc         []*EmailAddress            `json:"cc,omitempty"`
+	Subject    string                     `json:"subject,omitempty"`
+	ReceivedAt *time.Time                 `json:"receivedAt,omitempty"`
+	Preview    string                     `json:"preview,omitempty"`
+	BodyValues map[string]*EmailBodyValue `json:"bodyValues,omitempty"`
+	TextBody   []*EmailBodyPart           `json:"textBody,omitempty"`
+
+	ListUnsubscribe     []string `json:"header:List-Unsubscribe:asURLs,omitempty"`
+	ListUnsubscribePost string   `json:"header:List-Unsubscribe-Post:asText,omitempty"`
+}
+
+type EmailFilter struct {
+	From   string     `json:"from,omitempty"`
+	To     string     `json:"to,omitempty"`
+	After  *time.Time `json:"after,omitempty"`
+	Before *time.Time `json:"before,omitempty"`
+}
+
+type EmailQueryRequest struct {
+	AccountID      string        `json:"accountId"`
+	Filter         *EmailFilter  `json:"filter,omitempty"`
+	Sort           []*Comparator `json:"sort,omitempty"`
+	Limit          int           `json:"limit,omitempty"`
+	CalculateTotal bool          `json:"calculateTotal,omitempty"`
+}
+
+type EmailQueryResponse struct {
+	IDs   []string `json:"ids"`
+	Total int      `json:"total"`
+}
+
+type EmailGetRequest struct {
+	AccountID  string           `json:"accountId"`
+	IDs        []string         `json:"ids,omitempty"`
+	IDsRef     *ResultReference `json:"#ids,omitempty"`
+	Properties []string         `json:"properties,omitempty"`
+}
+
+type EmailGetResponse struct {
+	List     []*Email `json:"list"`
+	NotFound []string `json:"notFound"`
+}
+
+type EmailSetRequest struct {
+	AccountID string            `json:"accountId"`
+	Create    map[string]*Email `json:"create,omitempty"`
+}
+
+type EmailSetResponse struct {
+	Created    map[string]*Email    `json:"created"`
+	NotCreated map[string]*SetError `json:"notCreated"`
+}
+
+type Mailbox struct {
+	ID   string `json:"id"`
+	Role string `json:"role,omitempty"`
+}
+
+type MailboxGetRequest struct {
+	AccountID  string   `json:"accountId"`
+	Properties []string `json:"properties,omitempty"`
+}
+
+type MailboxGetResponse struct {
+	List []*Mailbox `json:"list"`
+}
+
+type Identity struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type IdentityGetRequest struct {
+	AccountID string `json:"accountId"`
+}
+
+type IdentityGetResponse struct {
+	List []*Identity `json:"list"`
+}
+
+type IdentitySetRequest struct {
+	AccountID string               `json:"accountId"`
+	Create    map[string]*Identity `json:"create,omitempty"`
+}
+
+type IdentitySetResponse struct {
+	Created    map[string]*Identity `json:"created"`
+	NotCreated map[string]*SetError `json:"notCreated"`
+}
+
+type EmailSubmission struct {
+	ID         string `json:"id,omitempty"`
+	IdentityID string `json:"identityId,omitempty"`
+	EmailID    string `json:"emailId,omitempty"`
+}
+
+type EmailSubmissionSetRequest struct {
+	AccountID            string                      `json:"accountId"`
+	Create               map[string]*EmailSubmission `json:"create,omitempty"`
+	OnSuccessUpdateEmail map[string]map[string]any   `json:"onSuccessUpdateEmail,omitempty"`
+}
+
+type EmailSubmissionSetResponse struct {
+	Created    map[string]*EmailSubmission `json:"created"`
+	NotCreated map[string]*SetError        `json:"notCreated"`
 }
 
 type Invocation[T any] struct {
@@ -3,6 +3,7 @@ package httpserver
 import (
 	"context"
 	"errors"
+	"expvar"
 	"github.com/L11R/masked-email-bot/internal/domain"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -30,6 +31,9 @@ func NewDelivery(logger *zap.Logger, config *Config, service domain.Service) (do
 		logger: logger,
 	}))
 	r.Get("/redirect", a.handleOAuth2Redirect)
+	if config.Metrics {
+		r.Handle("/debug/vars", expvar.Handler())
+	}
 
 	a.server = &http.Server{
 		Addr:    config.Address,
@@ -2,4 +2,5 @@ package httpserver
 
 type Config struct {
 	Address string `env:"HTTP_ADDRESS,required"`
+	Metrics bool   `env:"HTTP_METRICS,default=false"`
 }
@@ -0,0 +1,10 @@
+package scheduler
+
+import "time"
+
+type Config struct {
+	ForumPollInterval time.Duration `env:"SCHEDULER_FORUM_POLL_INTERVAL,default=5m"`
+	DigestInterval    time.Duration `env:"SCHEDULER_DIGEST_INTERVAL,default=10m"`
+	BreachesInterval  time.Duration `env:"SCHEDULER_BREACHES_INTERVAL,default=6h"`
+	PurgeInterval     time.Duration `env:"SCHEDULER_PURGE_INTERVAL,default=24h"`
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+type delivery struct {
+	logger  *zap.Logger
+	config  *Config
+	service domain.Service
+
+	stop chan struct{}
+}
+
+func NewDelivery(logger *zap.Logger, config *Config, service domain.Service) (domain.Delivery, error) {
+	return &delivery{
+		logger:  logger,
+		config:  config,
+		service: service,
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// ListenAndServe runs periodic jobs until Shutdown is called.
+func (d *delivery) ListenAndServe() error {
+	forumTicker := time.NewTicker(d.config.ForumPollInterval)
+	defer forumTicker.Stop()
+	digestTicker := time.NewTicker(d.config.DigestInterval)
+	defer digestTicker.Stop()
+	breachesTicker := time.NewTicker(d.config.BreachesInterval)
+	defer breachesTicker.Stop()
+	purgeTicker := time.NewTicker(d.config.PurgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return nil
+		case <-forumTicker.C:
+			if err := d.service.PollForumMails(); err != nil {
+				d.logger.Error("Error while polling forum mails!", zap.Error(err))
+			}
+		case <-digestTicker.C:
+			if err := d.service.SendDigests(); err != nil {
+				d.logger.Error("Error while sending digests!", zap.Error(err))
+			}
+		case <-breachesTicker.C:
+			if err := d.service.CheckBreaches(); err != nil {
+				d.logger.Error("Error while checking breaches!", zap.Error(err))
+			}
+		case <-purgeTicker.C:
+			if err := d.service.PurgeMaskedEmailMessages(); err != nil {
+				d.logger.Error("Error while purging masked email messages!", zap.Error(err))
+			}
+			if err := d.service.PurgeCallbacks(); err != nil {
+				d.logger.Error("Error while purging expired callbacks!", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Shutdown stops running periodic jobs.
+func (d *delivery) Shutdown(_ context.Context) error {
+	close(d.stop)
+	return nil
+}
@@ -99,33 +99,110 @@ func (a *adapter) UpdateLanguageCode(telegramID int64, languageCode string) erro
 	return nil
 }
 
+func (a *adapter) ChooseLanguageCode(telegramID int64, languageCode string) error {
+	_, err := a.db.Exec(
+		`UPDATE users SET lang = ?, lang_chosen = true WHERE telegram_id = ?`,
+		languageCode,
+		telegramID,
+	)
+	if err != nil {
+		a.logger.Error("Error while choosing a language code!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
 func (a *adapter) GetUser(telegramID int64) (*domain.User, error) {
 	row := a.db.QueryRow(
-		`SELECT telegram_id, fastmail_token, lang FROM users WHERE telegram_id = ?`,
+		`SELECT `+userColumns+` FROM users WHERE telegram_id = ?`,
 		telegramID,
 	)
 
+	user, err := a.scanUser(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNoUser
+		}
+
+		a.logger.Error("Error while getting a user!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return user, nil
+}
+
+func (a *adapter) GetAuthorizedUsers() ([]*domain.User, error) {
+	rows, err := a.db.Query(`SELECT ` + userColumns + ` FROM users WHERE fastmail_token IS NOT NULL`)
+	if err != nil {
+		a.logger.Error("Error while getting authorized users!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0)
+	for rows.Next() {
+		user, err := a.scanUser(rows)
+		if err != nil {
+			a.logger.Error("Error while scanning a user!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting authorized users!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return users, nil
+}
+
+const userColumns = `telegram_id, fastmail_token, lang, lang_chosen, forum_chat_id, forum_polled_at, forum_polled_ids,
+	digest_schedule, digest_timezone, digest_hour, digest_sent_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func (a *adapter) scanUser(row scanner) (*domain.User, error) {
 	var user domain.User
 	var tokenStr sql.NullString
+	var forumChatID sql.NullInt64
+	var forumPolledAt sql.NullTime
+	var forumPolledIDs sql.NullString
+	var digestSentAt sql.NullTime
 	if err := row.Scan(
 		&user.TelegramID,
 		&tokenStr,
 		&user.LanguageCode,
+		&user.LanguageChosen,
+		&forumChatID,
+		&forumPolledAt,
+		&forumPolledIDs,
+		&user.Digest.Schedule,
+		&user.Digest.Timezone,
+		&user.Digest.Hour,
+		&digestSentAt,
 	); err != nil {
-		if errors.Is(err, sqlite3.ErrNotFound) {
-			return nil, domain.ErrNoUser
-		}
-
-		a.logger.Error("Error while getting a user!", zap.Error(err))
-		return nil, domain.ErrSqliteInternal
+		return nil, err
 	}
 
 	if tokenStr.Valid {
 		if err := json.Unmarshal([]byte(tokenStr.String), &user.FastmailToken); err != nil {
-			a.logger.Error("Error while decoding a Fastmail token!", zap.Error(err))
-			return nil, domain.ErrSqliteInternal
+			return nil, err
+		}
+	}
+
+	user.ForumChatID = forumChatID.Int64
+	user.ForumPolledAt = forumPolledAt.Time
+	if forumPolledIDs.Valid {
+		if err := json.Unmarshal([]byte(forumPolledIDs.String), &user.ForumPolledIDs); err != nil {
+			return nil, err
 		}
 	}
+	user.DigestSentAt = digestSentAt.Time
 
 	return &user, nil
 }
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+func (a *adapter) BlockDomain(telegramID int64, domainName string, blockedAt time.Time) error {
+	_, err := a.db.Exec(
+		`INSERT INTO blocked_domains (telegram_id, domain, blocked_at) VALUES (?, ?, ?)
+		ON CONFLICT (telegram_id, domain) DO NOTHING`,
+		telegramID,
+		domainName,
+		blockedAt,
+	)
+	if err != nil {
+		a.logger.Error("Error while blocking a domain!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) UnblockDomain(telegramID int64, domainName string) error {
+	_, err := a.db.Exec(
+		`DELETE FROM blocked_domains WHERE telegram_id = ? AND domain = ?`,
+		telegramID,
+		domainName,
+	)
+	if err != nil {
+		a.logger.Error("Error while unblocking a domain!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) IsDomainBlocked(telegramID int64, domainName string) (bool, error) {
+	var blocked bool
+	if err := a.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM blocked_domains WHERE telegram_id = ? AND domain = ?)`,
+		telegramID,
+		domainName,
+	).Scan(&blocked); err != nil {
+		a.logger.Error("Error while checking a blocked domain!", zap.Error(err))
+		return false, domain.ErrSqliteInternal
+	}
+
+	return blocked, nil
+}
+
+func (a *adapter) GetBlockedDomains(telegramID int64) ([]*domain.BlockedDomain, error) {
+	rows, err := a.db.Query(
+		`SELECT domain, blocked_at FROM blocked_domains WHERE telegram_id = ? ORDER BY domain`,
+		telegramID,
+	)
+	if err != nil {
+		a.logger.Error("Error while getting blocked domains!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	blockedDomains := make([]*domain.BlockedDomain, 0)
+	for rows.Next() {
+		var blockedDomain domain.BlockedDomain
+		if err := rows.Scan(&blockedDomain.Domain, &blockedDomain.BlockedAt); err != nil {
+			a.logger.Error("Error while scanning a blocked domain!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		blockedDomains = append(blockedDomains, &blockedDomain)
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting blocked domains!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return blockedDomains, nil
+}
@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+func (a *adapter) IsBreachNotified(telegramID int64, breachName string) (bool, error) {
+	var notified bool
+	if err := a.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM notified_breaches WHERE telegram_id = ? AND breach_name = ?)`,
+		telegramID,
+		breachName,
+	).Scan(&notified); err != nil {
+		a.logger.Error("Error while checking a notified breach!", zap.Error(err))
+		return false, domain.ErrSqliteInternal
+	}
+
+	return notified, nil
+}
+
+func (a *adapter) SaveNotifiedBreach(telegramID int64, breachName string, notifiedAt time.Time) error {
+	_, err := a.db.Exec(
+		`INSERT INTO notified_breaches (telegram_id, breach_name, notified_at) VALUES (?, ?, ?)
+		ON CONFLICT (telegram_id, breach_name) DO UPDATE SET notified_at = excluded.notified_at`,
+		telegramID,
+		breachName,
+		notifiedAt,
+	)
+	if err != nil {
+		a.logger.Error("Error while saving a notified breach!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+// SaveCallback stores the callback, a button sent again only gets its expiration moved.
+func (a *adapter) SaveCallback(callback *domain.Callback) error {
+	args, err := json.Marshal(callback.Args)
+	if err != nil {
+		a.logger.Error("Error while encoding callback arguments!", zap.Error(err))
+		return domain.ErrJSONEncoding
+	}
+
+	if _, err := a.db.Exec(
+		`INSERT INTO callbacks (token, action, args, telegram_id, expires_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (token) DO UPDATE SET expires_at = excluded.expires_at`,
+		callback.Token,
+		callback.Action,
+		string(args),
+		callback.TelegramID,
+		callback.ExpiresAt,
+	); err != nil {
+		a.logger.Error("Error while saving a callback!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) GetCallback(token string) (*domain.Callback, error) {
+	callback := domain.Callback{Token: token}
+	var args string
+	if err := a.db.QueryRow(
+		`SELECT action, args, telegram_id, expires_at FROM callbacks WHERE token = ?`,
+		token,
+	).Scan(
+		&callback.Action,
+		&args,
+		&callback.TelegramID,
+		&callback.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNoCallback
+		}
+
+		a.logger.Error("Error while getting a callback!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	if err := json.Unmarshal([]byte(args), &callback.Args); err != nil {
+		a.logger.Error("Error while decoding callback arguments!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return &callback, nil
+}
+
+func (a *adapter) DeleteCallbacksExpiredBefore(expiresAt time.Time) error {
+	if _, err := a.db.Exec(`DELETE FROM callbacks WHERE expires_at < ?`, expiresAt); err != nil {
+		a.logger.Error("Error while deleting expired callbacks!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+// SaveConversation stores the conversation, replacing the one the user had in the chat.
+func (a *adapter) SaveConversation(conversation *domain.Conversation) error {
+	data, err := json.Marshal(conversation.Data)
+	if err != nil {
+		a.logger.Error("Error while encoding conversation data!", zap.Error(err))
+		return domain.ErrJSONEncoding
+	}
+
+	if _, err := a.db.Exec(
+		`INSERT OR REPLACE INTO conversations (chat_id, telegram_id, step, data, prompt_message_id, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		conversation.ChatID,
+		conversation.TelegramID,
+		conversation.Step,
+		string(data),
+		conversation.PromptMessageID,
+		conversation.ExpiresAt,
+	); err != nil {
+		a.logger.Error("Error while saving a conversation!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) GetConversation(chatID, telegramID int64) (*domain.Conversation, error) {
+	conversation := domain.Conversation{
+		ChatID:     chatID,
+		TelegramID: telegramID,
+	}
+	var data string
+	if err := a.db.QueryRow(
+		`SELECT step, data, prompt_message_id, expires_at FROM conversations WHERE chat_id = ? AND telegram_id = ?`,
+		chatID,
+		telegramID,
+	).Scan(
+		&conversation.Step,
+		&data,
+		&conversation.PromptMessageID,
+		&conversation.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNoConversation
+		}
+
+		a.logger.Error("Error while getting a conversation!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	if err := json.Unmarshal([]byte(data), &conversation.Data); err != nil {
+		a.logger.Error("Error while decoding conversation data!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return &conversation, nil
+}
+
+func (a *adapter) DeleteConversation(chatID, telegramID int64) error {
+	if _, err := a.db.Exec(
+		`DELETE FROM conversations WHERE chat_id = ? AND telegram_id = ?`,
+		chatID,
+		telegramID,
+	); err != nil {
+		a.logger.Error("Error while deleting a conversation!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) DeleteConversationsExpiredBefore(expiresAt time.Time) error {
+	if _, err := a.db.Exec(`DELETE FROM conversations WHERE expires_at < ?`, expiresAt); err != nil {
+		a.logger.Error("Error while deleting expired conversations!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
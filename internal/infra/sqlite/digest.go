@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+func (a *adapter) GetDigestUsers() ([]*domain.User, error) {
+	rows, err := a.db.Query(
+		`SELECT `+userColumns+` FROM users WHERE digest_schedule != ? AND fastmail_token IS NOT NULL`,
+		domain.DigestScheduleOff,
+	)
+	if err != nil {
+		a.logger.Error("Error while getting digest users!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0)
+	for rows.Next() {
+		user, err := a.scanUser(rows)
+		if err != nil {
+			a.logger.Error("Error while scanning a user!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting digest users!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return users, nil
+}
+
+func (a *adapter) UpdateDigestSettings(telegramID int64, settings *domain.DigestSettings) error {
+	_, err := a.db.Exec(
+		`UPDATE users SET digest_schedule = ?, digest_timezone = ?, digest_hour = ? WHERE telegram_id = ?`,
+		settings.Schedule,
+		settings.Timezone,
+		settings.Hour,
+		telegramID,
+	)
+	if err != nil {
+		a.logger.Error("Error while updating digest settings!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) UpdateDigestSentAt(telegramID int64, sentAt time.Time) error {
+	_, err := a.db.Exec(
+		`UPDATE users SET digest_sent_at = ? WHERE telegram_id = ?`,
+		sentAt,
+		telegramID,
+	)
+	if err != nil {
+		a.logger.Error("Error while updating a digest send time!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+func (a *adapter) SaveMaskedEmailEvent(telegramID int64, event *domain.MaskedEmailEvent) error {
+	_, err := a.db.Exec(
+		`INSERT INTO masked_email_events (telegram_id, masked_email_id, event, details, created_at) VALUES (?, ?, ?, ?, ?)`,
+		telegramID,
+		event.MaskedEmailID,
+		event.Type,
+		event.Details,
+		event.CreatedAt,
+	)
+	if err != nil {
+		a.logger.Error("Error while saving a masked email event!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) GetMaskedEmailEvents(telegramID int64, maskedEmailID string) ([]*domain.MaskedEmailEvent, error) {
+	rows, err := a.db.Query(
+		`SELECT event, details, created_at FROM masked_email_events
+		WHERE telegram_id = ? AND masked_email_id = ? ORDER BY created_at, id`,
+		telegramID,
+		maskedEmailID,
+	)
+	if err != nil {
+		a.logger.Error("Error while getting masked email events!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	events := make([]*domain.MaskedEmailEvent, 0)
+	for rows.Next() {
+		event := domain.MaskedEmailEvent{MaskedEmailID: maskedEmailID}
+		if err := rows.Scan(&event.Type, &event.Details, &event.CreatedAt); err != nil {
+			a.logger.Error("Error while scanning a masked email event!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting masked email events!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return events, nil
+}
+
+func (a *adapter) GetMaskedEmailEventCounts(telegramID int64) (map[domain.MaskedEmailEventType]int, error) {
+	rows, err := a.db.Query(
+		`SELECT event, COUNT(*) FROM masked_email_events WHERE telegram_id = ? GROUP BY event`,
+		telegramID,
+	)
+	if err != nil {
+		a.logger.Error("Error while counting masked email events!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.MaskedEmailEventType]int)
+	for rows.Next() {
+		var eventType domain.MaskedEmailEventType
+		var count int
+		if err := rows.Scan(&eventType, &count); err != nil {
+			a.logger.Error("Error while scanning a masked email event count!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		counts[eventType] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while counting masked email events!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return counts, nil
+}
+
+func (a *adapter) GetMaskedEmailIDsWithEvent(telegramID int64, eventType domain.MaskedEmailEventType) (map[string]bool, error) {
+	rows, err := a.db.Query(
+		`SELECT DISTINCT masked_email_id FROM masked_email_events WHERE telegram_id = ? AND event = ?`,
+		telegramID,
+		eventType,
+	)
+	if err != nil {
+		a.logger.Error("Error while getting masked emails with an event!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			a.logger.Error("Error while scanning a masked email ID!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		ids[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting masked emails with an event!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return ids, nil
+}
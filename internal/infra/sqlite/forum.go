@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+func (a *adapter) GetForumUsers() ([]*domain.User, error) {
+	rows, err := a.db.Query(
+		`SELECT ` + userColumns + ` FROM users WHERE forum_chat_id IS NOT NULL AND fastmail_token IS NOT NULL`,
+	)
+	if err != nil {
+		a.logger.Error("Error while getting forum users!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0)
+	for rows.Next() {
+		user, err := a.scanUser(rows)
+		if err != nil {
+			a.logger.Error("Error while scanning a user!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting forum users!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return users, nil
+}
+
+func (a *adapter) UpdateForumChat(telegramID, chatID int64) error {
+	forumChatID := sql.NullInt64{Int64: chatID, Valid: chatID != 0}
+
+	_, err := a.db.Exec(
+		`UPDATE users SET forum_chat_id = ?, forum_polled_at = NULL, forum_polled_ids = NULL WHERE telegram_id = ?`,
+		forumChatID,
+		telegramID,
+	)
+	if err != nil {
+		a.logger.Error("Error while updating a forum chat!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) UpdateForumPolledAt(telegramID int64, polledAt time.Time, polledIDs []string) error {
+	ids, err := json.Marshal(polledIDs)
+	if err != nil {
+		a.logger.Error("Error while encoding forum poll mail IDs!", zap.Error(err))
+		return domain.ErrJSONEncoding
+	}
+
+	_, err = a.db.Exec(
+		`UPDATE users SET forum_polled_at = ?, forum_polled_ids = ? WHERE telegram_id = ?`,
+		polledAt,
+		string(ids),
+		telegramID,
+	)
+	if err != nil {
+		a.logger.Error("Error while updating a forum poll time!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) CreateForumTopic(topic *domain.ForumTopic) error {
+	_, err := a.db.Exec(
+		`INSERT INTO forum_topics (telegram_id, masked_email_id, email, chat_id, thread_id) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (telegram_id, masked_email_id) DO UPDATE SET chat_id = excluded.chat_id, thread_id = excluded.thread_id`,
+		topic.TelegramID,
+		topic.MaskedEmailID,
+		topic.Email,
+		topic.ChatID,
+		topic.ThreadID,
+	)
+	if err != nil {
+		a.logger.Error("Error while creating a forum topic!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) getForumTopic(query string, args ...any) (*domain.ForumTopic, error) {
+	row := a.db.QueryRow(query, args...)
+
+	var topic domain.ForumTopic
+	if err := row.Scan(
+		&topic.TelegramID,
+		&topic.MaskedEmailID,
+		&topic.Email,
+		&topic.ChatID,
+		&topic.ThreadID,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNoForumTopic
+		}
+
+		a.logger.Error("Error while getting a forum topic!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return &topic, nil
+}
+
+func (a *adapter) GetForumTopic(telegramID int64, maskedEmailID string) (*domain.ForumTopic, error) {
+	return a.getForumTopic(
+		`SELECT telegram_id, masked_email_id, email, chat_id, thread_id FROM forum_topics
+		WHERE telegram_id = ? AND masked_email_id = ?`,
+		telegramID,
+		maskedEmailID,
+	)
+}
+
+func (a *adapter) GetForumTopicByEmail(telegramID int64, email string) (*domain.ForumTopic, error) {
+	return a.getForumTopic(
+		`SELECT telegram_id, masked_email_id, email, chat_id, thread_id FROM forum_topics
+		WHERE telegram_id = ? AND email = ? COLLATE NOCASE`,
+		telegramID,
+		email,
+	)
+}
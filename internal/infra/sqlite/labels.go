@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+func (a *adapter) AddMaskedEmailsLabel(telegramID int64, ids []string, label string, createdAt time.Time) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		a.logger.Error("Error while beginning a transaction!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO masked_email_labels (telegram_id, masked_email_id, label, created_at) VALUES (?, ?, ?, ?)`,
+			telegramID,
+			id,
+			label,
+			createdAt,
+		); err != nil {
+			a.logger.Error("Error while adding a label!", zap.Error(err))
+			return domain.ErrSqliteInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.logger.Error("Error while committing a transaction!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) RemoveMaskedEmailsLabel(telegramID int64, ids []string, label string) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		a.logger.Error("Error while beginning a transaction!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if _, err := tx.Exec(
+			`DELETE FROM masked_email_labels WHERE telegram_id = ? AND masked_email_id = ? AND label = ?`,
+			telegramID,
+			id,
+			label,
+		); err != nil {
+			a.logger.Error("Error while removing a label!", zap.Error(err))
+			return domain.ErrSqliteInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.logger.Error("Error while committing a transaction!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+// GetMaskedEmailLabels returns labels of every labelled masked email of the user, keyed by masked email ID.
+func (a *adapter) GetMaskedEmailLabels(telegramID int64) (map[string][]string, error) {
+	rows, err := a.db.Query(
+		`SELECT masked_email_id, label FROM masked_email_labels WHERE telegram_id = ? ORDER BY masked_email_id, label`,
+		telegramID,
+	)
+	if err != nil {
+		a.logger.Error("Error while getting labels!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	labels := make(map[string][]string)
+	for rows.Next() {
+		var id, label string
+		if err := rows.Scan(&id, &label); err != nil {
+			a.logger.Error("Error while scanning a label!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		labels[id] = append(labels[id], label)
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting labels!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return labels, nil
+}
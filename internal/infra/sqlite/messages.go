@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+// SaveMaskedEmailMessage binds a bot message to the masked email it shows, an edited message gets the new one.
+func (a *adapter) SaveMaskedEmailMessage(message *domain.MaskedEmailMessage) error {
+	if _, err := a.db.Exec(
+		`INSERT OR REPLACE INTO masked_email_messages (chat_id, message_id, telegram_id, masked_email_id, email, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		message.ChatID,
+		message.MessageID,
+		message.TelegramID,
+		message.MaskedEmailID,
+		message.Email,
+		message.CreatedAt,
+	); err != nil {
+		a.logger.Error("Error while saving a masked email message!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) GetMaskedEmailMessage(chatID int64, messageID int) (*domain.MaskedEmailMessage, error) {
+	message := domain.MaskedEmailMessage{
+		ChatID:    chatID,
+		MessageID: messageID,
+	}
+	if err := a.db.QueryRow(
+		`SELECT telegram_id, masked_email_id, email, created_at FROM masked_email_messages
+		WHERE chat_id = ? AND message_id = ?`,
+		chatID,
+		messageID,
+	).Scan(
+		&message.TelegramID,
+		&message.MaskedEmailID,
+		&message.Email,
+		&message.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNoMaskedEmailMessage
+		}
+
+		a.logger.Error("Error while getting a masked email message!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return &message, nil
+}
+
+func (a *adapter) DeleteMaskedEmailMessagesCreatedBefore(createdAt time.Time) error {
+	if _, err := a.db.Exec(`DELETE FROM masked_email_messages WHERE created_at < ?`, createdAt); err != nil {
+		a.logger.Error("Error while deleting old masked email messages!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+func (a *adapter) CreateSelection(selection *domain.Selection) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		a.logger.Error("Error while beginning a transaction!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO selections (chat_id, message_id, telegram_id, kind, page, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		selection.ChatID,
+		selection.MessageID,
+		selection.TelegramID,
+		selection.Kind,
+		selection.Page,
+		selection.CreatedAt,
+	); err != nil {
+		a.logger.Error("Error while creating a selection!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	for i, item := range selection.Items {
+		if _, err := tx.Exec(
+			`INSERT INTO selection_items (chat_id, message_id, position, item_id, label, selected) VALUES (?, ?, ?, ?, ?, ?)`,
+			selection.ChatID,
+			selection.MessageID,
+			i,
+			item.ID,
+			item.Label,
+			item.Selected,
+		); err != nil {
+			a.logger.Error("Error while creating a selection item!", zap.Error(err))
+			return domain.ErrSqliteInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.logger.Error("Error while committing a transaction!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) getSelection(where string, args ...any) (*domain.Selection, error) {
+	var selection domain.Selection
+	if err := a.db.QueryRow(
+		`SELECT chat_id, message_id, telegram_id, kind, page, created_at FROM selections WHERE `+where,
+		args...,
+	).Scan(
+		&selection.ChatID,
+		&selection.MessageID,
+		&selection.TelegramID,
+		&selection.Kind,
+		&selection.Page,
+		&selection.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNoSelection
+		}
+
+		a.logger.Error("Error while getting a selection!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	rows, err := a.db.Query(
+		`SELECT item_id, label, selected FROM selection_items WHERE chat_id = ? AND message_id = ? ORDER BY position`,
+		selection.ChatID,
+		selection.MessageID,
+	)
+	if err != nil {
+		a.logger.Error("Error while getting selection items!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.SelectionItem
+		if err := rows.Scan(&item.ID, &item.Label, &item.Selected); err != nil {
+			a.logger.Error("Error while scanning a selection item!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		selection.Items = append(selection.Items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting selection items!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return &selection, nil
+}
+
+func (a *adapter) GetSelection(chatID int64, messageID int) (*domain.Selection, error) {
+	return a.getSelection(`chat_id = ? AND message_id = ?`, chatID, messageID)
+}
+
+func (a *adapter) UpdateSelection(selection *domain.Selection) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		a.logger.Error("Error while beginning a transaction!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE selections SET page = ? WHERE chat_id = ? AND message_id = ?`,
+		selection.Page,
+		selection.ChatID,
+		selection.MessageID,
+	); err != nil {
+		a.logger.Error("Error while updating a selection!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	for i, item := range selection.Items {
+		if _, err := tx.Exec(
+			`UPDATE selection_items SET selected = ? WHERE chat_id = ? AND message_id = ? AND position = ?`,
+			item.Selected,
+			selection.ChatID,
+			selection.MessageID,
+			i,
+		); err != nil {
+			a.logger.Error("Error while updating a selection item!", zap.Error(err))
+			return domain.ErrSqliteInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.logger.Error("Error while committing a transaction!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) DeleteSelection(chatID int64, messageID int) error {
+	if _, err := a.db.Exec(
+		`DELETE FROM selection_items WHERE chat_id = ? AND message_id = ?`,
+		chatID,
+		messageID,
+	); err != nil {
+		a.logger.Error("Error while deleting selection items!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	if _, err := a.db.Exec(
+		`DELETE FROM selections WHERE chat_id = ? AND message_id = ?`,
+		chatID,
+		messageID,
+	); err != nil {
+		a.logger.Error("Error while deleting a selection!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) DeleteSelectionsCreatedBefore(createdAt time.Time) error {
+	if _, err := a.db.Exec(
+		`DELETE FROM selection_items WHERE (chat_id, message_id) IN (
+			SELECT chat_id, message_id FROM selections WHERE created_at < ?
+		)`,
+		createdAt,
+	); err != nil {
+		a.logger.Error("Error while deleting old selection items!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	if _, err := a.db.Exec(`DELETE FROM selections WHERE created_at < ?`, createdAt); err != nil {
+		a.logger.Error("Error while deleting old selections!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+func (a *adapter) CreateUndo(undo *domain.Undo) (int64, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		a.logger.Error("Error while beginning a transaction!", zap.Error(err))
+		return 0, domain.ErrSqliteInternal
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO undos (telegram_id, label, created_at) VALUES (?, ?, ?)`,
+		undo.TelegramID,
+		undo.Label,
+		undo.CreatedAt,
+	)
+	if err != nil {
+		a.logger.Error("Error while creating an undo!", zap.Error(err))
+		return 0, domain.ErrSqliteInternal
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		a.logger.Error("Error while getting an undo ID!", zap.Error(err))
+		return 0, domain.ErrSqliteInternal
+	}
+
+	for _, item := range undo.Items {
+		if _, err := tx.Exec(
+			`INSERT INTO undo_items (undo_id, masked_email_id, state, description) VALUES (?, ?, ?, ?)`,
+			id,
+			item.MaskedEmailID,
+			item.State,
+			item.Description,
+		); err != nil {
+			a.logger.Error("Error while creating an undo item!", zap.Error(err))
+			return 0, domain.ErrSqliteInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		a.logger.Error("Error while committing a transaction!", zap.Error(err))
+		return 0, domain.ErrSqliteInternal
+	}
+
+	return id, nil
+}
+
+func (a *adapter) GetUndo(id int64) (*domain.Undo, error) {
+	undo := domain.Undo{ID: id}
+	if err := a.db.QueryRow(
+		`SELECT telegram_id, label, created_at FROM undos WHERE id = ?`,
+		id,
+	).Scan(
+		&undo.TelegramID,
+		&undo.Label,
+		&undo.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNoUndo
+		}
+
+		a.logger.Error("Error while getting an undo!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	rows, err := a.db.Query(
+		`SELECT masked_email_id, state, description FROM undo_items WHERE undo_id = ? ORDER BY masked_email_id`,
+		id,
+	)
+	if err != nil {
+		a.logger.Error("Error while getting undo items!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.UndoItem
+		if err := rows.Scan(&item.MaskedEmailID, &item.State, &item.Description); err != nil {
+			a.logger.Error("Error while scanning an undo item!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		undo.Items = append(undo.Items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting undo items!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return &undo, nil
+}
+
+func (a *adapter) DeleteUndo(id int64) error {
+	if _, err := a.db.Exec(`DELETE FROM undo_items WHERE undo_id = ?`, id); err != nil {
+		a.logger.Error("Error while deleting undo items!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	if _, err := a.db.Exec(`DELETE FROM undos WHERE id = ?`, id); err != nil {
+		a.logger.Error("Error while deleting an undo!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) DeleteUndosCreatedBefore(createdAt time.Time) error {
+	if _, err := a.db.Exec(
+		`DELETE FROM undo_items WHERE undo_id IN (SELECT id FROM undos WHERE created_at < ?)`,
+		createdAt,
+	); err != nil {
+		a.logger.Error("Error while deleting old undo items!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	if _, err := a.db.Exec(`DELETE FROM undos WHERE created_at < ?`, createdAt); err != nil {
+		a.logger.Error("Error while deleting old undos!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
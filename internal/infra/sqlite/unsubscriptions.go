@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+func (a *adapter) SaveUnsubscription(unsubscription *domain.Unsubscription) error {
+	_, err := a.db.Exec(
+		`INSERT INTO unsubscriptions (telegram_id, masked_email, sender, method, requested_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (telegram_id, masked_email, sender) DO UPDATE SET method = excluded.method, requested_at = excluded.requested_at`,
+		unsubscription.TelegramID,
+		unsubscription.MaskedEmail,
+		unsubscription.Sender,
+		unsubscription.Method,
+		unsubscription.RequestedAt,
+	)
+	if err != nil {
+		a.logger.Error("Error while saving an unsubscription!", zap.Error(err))
+		return domain.ErrSqliteInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) GetUnsubscriptions(telegramID int64) ([]*domain.Unsubscription, error) {
+	rows, err := a.db.Query(
+		`SELECT masked_email, sender, method, requested_at FROM unsubscriptions
+		WHERE telegram_id = ? ORDER BY requested_at DESC`,
+		telegramID,
+	)
+	if err != nil {
+		a.logger.Error("Error while getting unsubscriptions!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+	defer rows.Close()
+
+	unsubscriptions := make([]*domain.Unsubscription, 0)
+	for rows.Next() {
+		unsubscription := domain.Unsubscription{TelegramID: telegramID}
+		if err := rows.Scan(
+			&unsubscription.MaskedEmail,
+			&unsubscription.Sender,
+			&unsubscription.Method,
+			&unsubscription.RequestedAt,
+		); err != nil {
+			a.logger.Error("Error while scanning an unsubscription!", zap.Error(err))
+			return nil, domain.ErrSqliteInternal
+		}
+
+		unsubscriptions = append(unsubscriptions, &unsubscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		a.logger.Error("Error while getting unsubscriptions!", zap.Error(err))
+		return nil, domain.ErrSqliteInternal
+	}
+
+	return unsubscriptions, nil
+}
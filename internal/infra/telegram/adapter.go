@@ -1,6 +1,8 @@
 package telegram
 
 import (
+	"encoding/json"
+
 	"github.com/L11R/masked-email-bot/internal/domain"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
@@ -8,13 +10,14 @@ import (
 )
 
 type adapter struct {
-	logger *zap.Logger
-	config *Config
-	bundle *i18n.Bundle
-	bot    *tgbotapi.BotAPI
+	logger    *zap.Logger
+	config    *Config
+	languages *languages
+	bot       *tgbotapi.BotAPI
+	callbacks *callbackRegistry
 }
 
-func NewAdapter(logger *zap.Logger, config *Config, bundle *i18n.Bundle) (domain.Telegram, error) {
+func NewAdapter(logger *zap.Logger, config *Config, bundle *i18n.Bundle, callbacks domain.CallbackStore) (domain.Telegram, error) {
 	bot, err := tgbotapi.NewBotAPI(config.Token)
 	if err != nil {
 		return nil, err
@@ -23,15 +26,16 @@ func NewAdapter(logger *zap.Logger, config *Config, bundle *i18n.Bundle) (domain
 	bot.Debug = config.Debug
 
 	return &adapter{
-		logger: logger,
-		config: config,
-		bundle: bundle,
-		bot:    bot,
+		logger:    logger,
+		config:    config,
+		languages: newLanguages(bundle),
+		bot:       bot,
+		callbacks: newCallbackRegistry(logger, callbacks),
 	}, nil
 }
 
 func (a *adapter) SendMessage(telegramID int64, languageCode, messageID string) error {
-	localizer := i18n.NewLocalizer(a.bundle, languageCode)
+	localizer := a.languages.localizer(languageCode)
 
 	msg := tgbotapi.NewMessage(telegramID, localizer.MustLocalize(&i18n.LocalizeConfig{
 		MessageID: messageID,
@@ -45,3 +49,84 @@ func (a *adapter) SendMessage(telegramID int64, languageCode, messageID string)
 
 	return nil
 }
+
+func (a *adapter) IsForum(chatID int64) (bool, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+
+	resp, err := a.bot.MakeRequest("getChat", params)
+	if err != nil {
+		a.logger.Error("Error while getting a chat!", zap.Error(err))
+		return false, domain.ErrTelegramInternal
+	}
+
+	// The library predates forum topics, so the flag is decoded by hand
+	var chat struct {
+		IsForum bool `json:"is_forum"`
+	}
+	if err := json.Unmarshal(resp.Result, &chat); err != nil {
+		a.logger.Error("Error while decoding a chat!", zap.Error(err))
+		return false, domain.ErrTelegramInternal
+	}
+
+	return chat.IsForum, nil
+}
+
+func (a *adapter) CreateTopic(chatID int64, name string) (int, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonEmpty("name", name)
+
+	resp, err := a.bot.MakeRequest("createForumTopic", params)
+	if err != nil {
+		a.logger.Error("Error while creating a forum topic!", zap.Error(err))
+		return 0, domain.ErrTelegramInternal
+	}
+
+	var topic struct {
+		MessageThreadID int `json:"message_thread_id"`
+	}
+	if err := json.Unmarshal(resp.Result, &topic); err != nil {
+		a.logger.Error("Error while decoding a forum topic!", zap.Error(err))
+		return 0, domain.ErrTelegramInternal
+	}
+
+	return topic.MessageThreadID, nil
+}
+
+// sendToThread sends a MarkdownV2 message into a forum topic.
+func (a *adapter) sendToThread(chatID int64, threadID int, text string, markup interface{}) error {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("message_thread_id", threadID)
+	params.AddNonEmpty("text", text)
+	params.AddNonEmpty("parse_mode", tgbotapi.ModeMarkdownV2)
+	if err := params.AddInterface("reply_markup", markup); err != nil {
+		a.logger.Error("Error while encoding a reply markup!", zap.Error(err))
+		return domain.ErrTelegramInternal
+	}
+
+	if _, err := a.bot.MakeRequest("sendMessage", params); err != nil {
+		a.logger.Error("Error while sending a message to the forum topic!", zap.Error(err))
+		return domain.ErrTelegramInternal
+	}
+
+	return nil
+}
+
+func (a *adapter) SendTopicMessage(chatID int64, threadID int, languageCode, messageID string, templateData map[string]interface{}) error {
+	localizer := a.languages.localizer(languageCode)
+
+	return a.sendToThread(chatID, threadID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID:    messageID,
+		TemplateData: templateData,
+	}), nil)
+}
+
+func (a *adapter) SendMailPreview(chatID int64, threadID int, languageCode string, mail *domain.Mail) error {
+	localizer := a.languages.localizer(languageCode)
+
+	// The forum is a group of the user's own, so anyone in it may use the buttons
+	text, markup := mailMessage(localizer, a.callbacks, 0, mail)
+	return a.sendToThread(chatID, threadID, text, markup)
+}
@@ -0,0 +1,144 @@
+package telegram
+
+import (
+	"strings"
+	"unicode"
+)
+
+// commandFlag describes a --flag of a command, values of a repeated one are collected in order.
+type commandFlag struct {
+	name     string
+	hasValue bool
+	repeated bool
+}
+
+// argumentError is a mistake in command arguments, it's shown to the user along with the usage.
+type argumentError struct {
+	messageID    string
+	templateData map[string]interface{}
+}
+
+func (e *argumentError) Error() string {
+	return "invalid command arguments: " + e.messageID
+}
+
+// closingQuotes pairs opening quotes with closing ones, phone keyboards like to replace the straight ones.
+var closingQuotes = map[rune]rune{
+	'"':  '"',
+	'\'': '\'',
+	'“':  '”',
+	'„':  '“',
+	'«':  '»',
+}
+
+// splitArguments splits command arguments the way a shell does, quoted parts keep their spaces.
+func splitArguments(text string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	inArgument := false
+	var closing rune
+	quoted := false
+
+	for _, r := range text {
+		switch {
+		case quoted && r == closing:
+			quoted = false
+		case quoted:
+			current.WriteRune(r)
+		case unicode.IsSpace(r):
+			if inArgument {
+				args = append(args, current.String())
+				current.Reset()
+				inArgument = false
+			}
+		default:
+			inArgument = true
+			if c, ok := closingQuotes[r]; ok {
+				quoted, closing = true, c
+				continue
+			}
+			current.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, &argumentError{messageID: "TelegramArgumentsUnterminatedQuote"}
+	}
+	if inArgument {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// parseFlags reads "--name value" and "--name=value" arguments, flags without a value are set to an empty string.
+func parseFlags(args []string, flags []commandFlag) (map[string][]string, error) {
+	specs := make(map[string]commandFlag, len(flags))
+	for _, flag := range flags {
+		specs[flag.name] = flag
+	}
+
+	values := make(map[string][]string)
+	for i := 0; i < len(args); i++ {
+		name, ok := flagName(args[i])
+		if !ok {
+			return nil, &argumentError{
+				messageID:    "TelegramArgumentsUnexpected",
+				templateData: map[string]interface{}{"Argument": args[i]},
+			}
+		}
+
+		name, value, hasValue := strings.Cut(name, "=")
+		spec, ok := specs[name]
+		if !ok {
+			return nil, &argumentError{
+				messageID:    "TelegramArgumentsUnknownFlag",
+				templateData: map[string]interface{}{"Flag": "--" + name},
+			}
+		}
+
+		if _, ok := values[name]; ok && !spec.repeated {
+			return nil, &argumentError{
+				messageID:    "TelegramArgumentsRepeatedFlag",
+				templateData: map[string]interface{}{"Flag": "--" + name},
+			}
+		}
+
+		switch {
+		case !spec.hasValue && hasValue:
+			return nil, &argumentError{
+				messageID:    "TelegramArgumentsUnexpectedValue",
+				templateData: map[string]interface{}{"Flag": "--" + name},
+			}
+		case spec.hasValue && !hasValue:
+			if i+1 == len(args) || isFlag(args[i+1]) {
+				return nil, &argumentError{
+					messageID:    "TelegramArgumentsMissingValue",
+					templateData: map[string]interface{}{"Flag": "--" + name},
+				}
+			}
+			i++
+			value = args[i]
+		}
+
+		values[name] = append(values[name], value)
+	}
+
+	return values, nil
+}
+
+// flagName returns the name of a "--name" argument.
+func flagName(arg string) (string, bool) {
+	// Phone keyboards turn a double hyphen into a dash
+	name, ok := strings.CutPrefix(arg, "--")
+	if !ok {
+		name, ok = strings.CutPrefix(arg, "—")
+	}
+
+	return name, ok && name != ""
+}
+
+func isFlag(arg string) bool {
+	_, ok := flagName(arg)
+	return ok
+}
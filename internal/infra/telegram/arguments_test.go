@@ -0,0 +1,98 @@
+package telegram
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []string
+		wantErr string
+	}{
+		{"empty", "", []string{}, ""},
+		{"spaces", "  \t ", []string{}, ""},
+		{"words", "shop  news\tmail", []string{"shop", "news", "mail"}, ""},
+		{"straight double quotes", `--desc "my shop" x`, []string{"--desc", "my shop", "x"}, ""},
+		{"straight single quotes", `'my shop'`, []string{"my shop"}, ""},
+		{"typographic quotes", `“my shop” „news feed“ «online store»`, []string{"my shop", "news feed", "online store"}, ""},
+		{"other quote inside", `"it's"`, []string{"it's"}, ""},
+		{"quote inside a word", `--desc="my shop"`, []string{"--desc=my shop"}, ""},
+		{"empty quotes", `"" x`, []string{"", "x"}, ""},
+		{"unterminated", `"my shop`, nil, "TelegramArgumentsUnterminatedQuote"},
+		{"unterminated typographic", `«my shop`, nil, "TelegramArgumentsUnterminatedQuote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitArguments(tt.text)
+			if messageID := argumentErrorID(err); messageID != tt.wantErr {
+				t.Fatalf("splitArguments(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitArguments(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	flags := []commandFlag{
+		{name: "for", hasValue: true},
+		{name: "label", hasValue: true, repeated: true},
+		{name: "enabled"},
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string][]string
+		wantErr string
+	}{
+		{"none", []string{}, map[string][]string{}, ""},
+		{"separate value", []string{"--for", "shop.com"}, map[string][]string{"for": {"shop.com"}}, ""},
+		{"inline value", []string{"--for=shop.com"}, map[string][]string{"for": {"shop.com"}}, ""},
+		{"empty inline value", []string{"--for="}, map[string][]string{"for": {""}}, ""},
+		{"value with equals", []string{"--for=a=b"}, map[string][]string{"for": {"a=b"}}, ""},
+		{"dash alias", []string{"—for", "shop.com", "—enabled"}, map[string][]string{"for": {"shop.com"}, "enabled": {""}}, ""},
+		{"switch", []string{"--enabled"}, map[string][]string{"enabled": {""}}, ""},
+		{"repeated", []string{"--label", "a", "--label=b"}, map[string][]string{"label": {"a", "b"}}, ""},
+		{"positional", []string{"shop.com"}, nil, "TelegramArgumentsUnexpected"},
+		{"bare dashes", []string{"--"}, nil, "TelegramArgumentsUnexpected"},
+		{"single dash", []string{"-for", "shop.com"}, nil, "TelegramArgumentsUnexpected"},
+		{"unknown", []string{"--to", "shop.com"}, nil, "TelegramArgumentsUnknownFlag"},
+		{"repeated once only", []string{"--for", "a", "--for", "b"}, nil, "TelegramArgumentsRepeatedFlag"},
+		{"switch with value", []string{"--enabled=yes"}, nil, "TelegramArgumentsUnexpectedValue"},
+		{"missing value at end", []string{"--for"}, nil, "TelegramArgumentsMissingValue"},
+		{"flag instead of value", []string{"--for", "--enabled"}, nil, "TelegramArgumentsMissingValue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFlags(tt.args, flags)
+			if messageID := argumentErrorID(err); messageID != tt.wantErr {
+				t.Fatalf("parseFlags(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFlags(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+// argumentErrorID returns the message of an argument error, empty without an error.
+func argumentErrorID(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var argErr *argumentError
+	if !errors.As(err, &argErr) {
+		return "unexpected: " + err.Error()
+	}
+
+	return argErr.messageID
+}
@@ -0,0 +1,225 @@
+package telegram
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+func (d *delivery) blockCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	args := strings.Fields(update.Message.CommandArguments())
+	if len(args) != 1 {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramBlockUsage",
+		}))
+		msg.ParseMode = "MarkdownV2"
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	blockedDomain, result, err := d.service.BlockDomain(update.Message.From.ID, args[0])
+	if errors.Is(err, domain.ErrInvalidDomain) {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramInvalidDomain",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramDomainBlockedDone",
+		TemplateData: map[string]interface{}{
+			"Domain":   blockedDomain.Domain,
+			"Disabled": len(result.Succeeded),
+			"Failed":   len(result.Failed),
+		},
+	}))
+	msg.DisableWebPagePreview = true
+	if markup := d.undoMarkup(localizer, update.Message.From.ID, result); markup != nil {
+		msg.ReplyMarkup = markup
+	}
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
+
+// blocksMessage renders the block list with a button to unblock every domain.
+func (d *delivery) blocksMessage(localizer *i18n.Localizer, telegramID int64, blockedDomains []*domain.BlockedDomain) (string, tgbotapi.InlineKeyboardMarkup) {
+	if len(blockedDomains) == 0 {
+		return localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramBlocksEmpty"}),
+			tgbotapi.NewInlineKeyboardMarkup()
+	}
+
+	text := localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramBlocks",
+		TemplateData: map[string]interface{}{
+			"BlockedDomains": blockedDomains,
+		},
+	})
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(blockedDomains))
+	for _, blockedDomain := range blockedDomains {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			d.callbacks.button(
+				localizer.MustLocalize(&i18n.LocalizeConfig{
+					MessageID:    "TelegramUnblockButton",
+					TemplateData: map[string]interface{}{"Domain": blockedDomain.Domain},
+				}),
+				telegramID,
+				callbackUnblock,
+				blockedDomain.Domain,
+			),
+		))
+	}
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (d *delivery) blocksCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	blockedDomains, err := d.service.BlockedDomains(update.Message.From.ID)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	text, markup := d.blocksMessage(localizer, update.Message.From.ID, blockedDomains)
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+	msg.DisableWebPagePreview = true
+	if len(blockedDomains) > 0 {
+		msg.ReplyMarkup = markup
+	}
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
+
+func (d *delivery) unblockDomain(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	if err := d.service.UnblockDomain(update.CallbackQuery.From.ID, args[0]); err != nil {
+		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramError",
+		}))
+		callback.ShowAlert = true
+		if _, err := d.bot.Request(callback); err != nil {
+			d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+		}
+		return err
+	}
+
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID:    "TelegramDomainUnblocked",
+		TemplateData: map[string]interface{}{"Domain": args[0]},
+	}))
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+
+	blockedDomains, err := d.service.BlockedDomains(update.CallbackQuery.From.ID)
+	if err != nil {
+		return err
+	}
+
+	text, markup := d.blocksMessage(localizer, update.CallbackQuery.From.ID, blockedDomains)
+	msg := tgbotapi.NewEditMessageTextAndMarkup(
+		update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		text,
+		markup,
+	)
+	msg.DisableWebPagePreview = true
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+	}
+
+	return nil
+}
+
+// sendDomainBlocked tells that the site is blocked, replying to the message so the override button can find it later.
+func (d *delivery) sendDomainBlocked(localizer *i18n.Localizer, message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramDomainBlocked",
+	}))
+	msg.ReplyToMessageID = message.MessageID
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		d.callbacks.button(
+			localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramDomainBlockedOverrideButton"}),
+			message.From.ID,
+			callbackOverride,
+		),
+	))
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+}
+
+// overrideBlockedDomain creates the masked email refused because of the block list, taking the link or /new from the replied message.
+func (d *delivery) overrideBlockedDomain(localizer *i18n.Localizer, update tgbotapi.Update, _ []string) error {
+	message := update.CallbackQuery.Message
+	if message == nil || message.ReplyToMessage == nil {
+		return errors.New("no message to override")
+	}
+
+	var maskedEmail *domain.MaskedEmail
+	var err error
+	if replied := message.ReplyToMessage; replied.IsCommand() && replied.Command() == "new" {
+		var request *domain.MaskedEmailRequest
+		request, err = parseNewCommand(replied.CommandArguments())
+		if err == nil && request == nil {
+			err = errors.New("no options to override")
+		}
+		if err == nil {
+			maskedEmail, err = d.service.CreateMaskedEmailForBlockedDomain(update.CallbackQuery.From.ID, request)
+		}
+	} else {
+		maskedEmail, err = d.service.GenerateMaskedEmailForBlockedDomain(update.CallbackQuery.From.ID, replied.Text)
+	}
+	if err != nil {
+		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramError",
+		}))
+		callback.ShowAlert = true
+		if _, err := d.bot.Request(callback); err != nil {
+			d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+		}
+		return err
+	}
+
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+
+	newMsg := d.newMaskedEmailMessage(localizer, message.Chat.ID, update.CallbackQuery.From.ID, maskedEmail)
+	msg := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, newMsg.Text)
+	if markup, ok := newMsg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+		msg.ReplyMarkup = &markup
+	}
+	msg.ParseMode = "MarkdownV2"
+	sent, err := d.bot.Send(msg)
+	if err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+		return nil
+	}
+	d.rememberMaskedEmail(update.CallbackQuery.From.ID, sent, maskedEmail)
+
+	return nil
+}
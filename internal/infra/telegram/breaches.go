@@ -0,0 +1,148 @@
+package telegram
+
+import (
+	"errors"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+// breachButtonsLimit fits two buttons per address into the keyboard.
+const breachButtonsLimit = keyboardButtonsLimit / 2
+
+func (a *adapter) SendBreachAlert(telegramID int64, languageCode string, alert *domain.BreachAlert) error {
+	localizer := a.languages.localizer(languageCode)
+
+	breachDate := ""
+	if !alert.Breach.BreachDate.IsZero() {
+		breachDate = alert.Breach.BreachDate.Format("2006-01-02")
+	}
+
+	msg := tgbotapi.NewMessage(telegramID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramBreachAlert",
+		TemplateData: map[string]interface{}{
+			"Title":        alert.Breach.Title,
+			"Domain":       alert.Breach.Domain,
+			"BreachDate":   breachDate,
+			"MaskedEmails": alert.MaskedEmails,
+		},
+	}))
+	msg.DisableWebPagePreview = true
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(alert.MaskedEmails))
+	for i, maskedEmail := range alert.MaskedEmails {
+		if i == breachButtonsLimit {
+			break
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			a.callbacks.button(
+				localizer.MustLocalize(&i18n.LocalizeConfig{
+					MessageID:    "TelegramBreachRotateButton",
+					TemplateData: map[string]interface{}{"Email": maskedEmail.Email},
+				}),
+				telegramID,
+				callbackRotate,
+				maskedEmail.ID,
+			),
+			a.callbacks.button(
+				localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramBreachDisableButton"}),
+				telegramID,
+				callbackDisable,
+				maskedEmail.ID,
+			),
+		))
+	}
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	if _, err := a.bot.Send(msg); err != nil {
+		a.logger.Error("Error while sending a breach alert!", zap.Error(err))
+		return domain.ErrTelegramInternal
+	}
+
+	return nil
+}
+
+func (d *delivery) rotateMaskedEmail(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	maskedEmail, result, err := d.service.RotateMaskedEmail(update.CallbackQuery.From.ID, args[0])
+	// The replacement exists even when the old address couldn't be disabled, so it is shown anyway
+	messageID := "TelegramEmailRotated"
+	switch {
+	case errors.Is(err, domain.ErrRotationIncomplete):
+		messageID = "TelegramEmailRotatedOldActive"
+	case errors.Is(err, domain.ErrDomainBlocked):
+		d.answerCallbackAlert(localizer, update, "TelegramRotateDomainBlocked")
+		return nil
+	case err != nil:
+		d.answerCallbackAlert(localizer, update, errorMessageID(err))
+		return err
+	}
+
+	d.answerCallbackAlert(localizer, update, "")
+
+	msg := tgbotapi.NewMessage(update.CallbackQuery.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: messageID,
+		TemplateData: map[string]interface{}{
+			"Email": maskedEmail.Email,
+		},
+	}))
+	msg.ParseMode = "MarkdownV2"
+	msg.ReplyToMessageID = update.CallbackQuery.Message.MessageID
+	if result != nil {
+		if markup := d.undoMarkup(localizer, update.CallbackQuery.From.ID, result); markup != nil {
+			msg.ReplyMarkup = markup
+		}
+	}
+	d.sendReply(msg, update.CallbackQuery.From.ID, maskedEmail)
+
+	return err
+}
+
+func (d *delivery) disableMaskedEmail(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	result, err := d.service.SetMaskedEmailsState(
+		update.CallbackQuery.From.ID,
+		[]string{args[0]},
+		domain.MaskedEmailStateDisabled,
+	)
+	if err == nil && len(result.Failed) > 0 {
+		err = domain.ErrFastmailInternal
+	}
+	if err != nil {
+		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramError",
+		}))
+		callback.ShowAlert = true
+		if _, err := d.bot.Request(callback); err != nil {
+			d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+		}
+		return err
+	}
+
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+
+	msg := tgbotapi.NewMessage(update.CallbackQuery.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramEmailDisabled",
+	}))
+	msg.ReplyToMessageID = update.CallbackQuery.Message.MessageID
+	if markup := d.undoMarkup(localizer, update.CallbackQuery.From.ID, result); markup != nil {
+		msg.ReplyMarkup = markup
+	}
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+const selectionKindBulk = "bulk"
+
+func (d *delivery) bulkCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	filter, ok := parseMaskedEmailFilter(update.Message.CommandArguments())
+	if !ok {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramListUsage",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	maskedEmails, err := d.service.MaskedEmails(update.Message.From.ID, filter)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	if len(maskedEmails) == 0 {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramServicesEmpty",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	return d.sendSelection(
+		localizer,
+		update.Message.Chat.ID,
+		update.Message.From.ID,
+		selectionKindBulk,
+		localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID:    "TelegramBulk",
+			TemplateData: map[string]interface{}{"Count": len(maskedEmails)},
+		}),
+		maskedEmailSelectionItems(maskedEmails),
+	)
+}
@@ -0,0 +1,185 @@
+package telegram
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+// callbackAction names what a button does, presses are dispatched to the handler registered for it.
+type callbackAction string
+
+const (
+	callbackEnable      callbackAction = "enable"
+	callbackInline      callbackAction = "inline"
+	callbackReply       callbackAction = "reply"
+	callbackUnsubscribe callbackAction = "unsub"
+	callbackSelection   callbackAction = "selection"
+	callbackCard        callbackAction = "card"
+	callbackRotate      callbackAction = "rotate"
+	callbackDisable     callbackAction = "disable"
+	callbackKeep        callbackAction = "keep"
+	callbackOverride    callbackAction = "override"
+	callbackUnblock     callbackAction = "unblock"
+	callbackLabel       callbackAction = "label"
+	callbackUndo        callbackAction = "undo"
+	callbackDescription callbackAction = "description"
+	callbackLanguage    callbackAction = "language"
+)
+
+// callbackLifetime is how long a button keeps working after it has been sent the last time.
+const callbackLifetime = 30 * 24 * time.Hour
+
+// inlineCallbackLifetime is shorter, every keystroke of an inline query registers a button and few of them get sent.
+const inlineCallbackLifetime = 24 * time.Hour
+
+// callbackHandler handles a press of a button with the arguments it has been registered with.
+type callbackHandler func(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error
+
+// callbackRegistry keeps actions of buttons on the server side, the buttons only carry a token.
+// The token fits the Telegram limit of 64 bytes whatever the arguments are, and can't be forged into another action.
+type callbackRegistry struct {
+	logger *zap.Logger
+	store  domain.CallbackStore
+}
+
+func newCallbackRegistry(logger *zap.Logger, store domain.CallbackStore) *callbackRegistry {
+	return &callbackRegistry{
+		logger: logger,
+		store:  store,
+	}
+}
+
+// callbackToken is the same for the same button, so keyboards rendered again reuse their callbacks instead of piling up new ones.
+func callbackToken(owner int64, action callbackAction, args []string) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatInt(owner, 10)))
+	h.Write([]byte{0})
+	h.Write([]byte(action))
+	for _, arg := range args {
+		h.Write([]byte{0})
+		h.Write([]byte(arg))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:18])
+}
+
+// button registers the action and returns a button triggering it, owner zero lets anyone in the chat press it.
+func (r *callbackRegistry) button(text string, owner int64, action callbackAction, args ...string) tgbotapi.InlineKeyboardButton {
+	return r.expiringButton(text, callbackLifetime, owner, action, args...)
+}
+
+// expiringButton is a button that stops working after the given lifetime.
+func (r *callbackRegistry) expiringButton(text string, lifetime time.Duration, owner int64, action callbackAction, args ...string) tgbotapi.InlineKeyboardButton {
+	callback := &domain.Callback{
+		Token:      callbackToken(owner, action, args),
+		Action:     string(action),
+		Args:       args,
+		TelegramID: owner,
+		ExpiresAt:  time.Now().UTC().Add(lifetime),
+	}
+
+	// An unregistered button answers as an expired one, which beats not sending the message at all
+	if err := r.store.SaveCallback(callback); err != nil {
+		r.logger.Error("Error while registering a callback!", zap.Error(err))
+	}
+
+	return tgbotapi.NewInlineKeyboardButtonData(text, callback.Token)
+}
+
+// callback returns the action behind the pressed button, expired ones are purged by the scheduler.
+func (r *callbackRegistry) callback(token string) (*domain.Callback, error) {
+	callback, err := r.store.GetCallback(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(callback.ExpiresAt) {
+		return nil, domain.ErrNoCallback
+	}
+
+	return callback, nil
+}
+
+// withoutAction returns the keyboard with buttons of the action removed.
+func (r *callbackRegistry) withoutAction(markup *tgbotapi.InlineKeyboardMarkup, action callbackAction) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0)
+	if markup == nil {
+		return tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+
+	for _, row := range markup.InlineKeyboard {
+		buttons := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, button := range row {
+			if button.CallbackData != nil {
+				if callback, err := r.store.GetCallback(*button.CallbackData); err == nil && callback.Action == string(action) {
+					continue
+				}
+			}
+			buttons = append(buttons, button)
+		}
+
+		if len(buttons) > 0 {
+			rows = append(rows, buttons)
+		}
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// handleCallback looks up the pressed button and runs the handler of its action.
+func (d *delivery) handleCallback(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	callback, err := d.callbacks.callback(update.CallbackData())
+	if errors.Is(err, domain.ErrNoCallback) {
+		d.answerCallbackAlert(localizer, update, "TelegramButtonExpired")
+		return nil
+	}
+	if err != nil {
+		d.answerCallbackAlert(localizer, update, "TelegramError")
+		return err
+	}
+
+	// Messages in groups are visible to everyone, but their buttons act on the account of one user
+	if callback.TelegramID != 0 && callback.TelegramID != update.CallbackQuery.From.ID {
+		d.answerCallbackAlert(localizer, update, "TelegramButtonNotOwned")
+		return nil
+	}
+
+	handler, ok := d.router.actions[callbackAction(callback.Action)]
+	if !ok {
+		d.answerCallbackAlert(localizer, update, "TelegramError")
+		return errors.New("unknown callback action: " + callback.Action)
+	}
+
+	return handler(localizer, update, callback.Args)
+}
+
+// errInvalidCallback is returned for buttons whose arguments don't make sense to their action.
+var errInvalidCallback = errors.New("invalid callback arguments")
+
+// invalidCallback stops the button from spinning and reports its arguments as invalid.
+func (d *delivery) invalidCallback(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	d.answerCallbackAlert(localizer, update, "TelegramError")
+	return errInvalidCallback
+}
+
+// answerCallbackAlert answers the callback query, a non-empty messageID is shown as an alert.
+func (d *delivery) answerCallbackAlert(localizer *i18n.Localizer, update tgbotapi.Update, messageID string) {
+	text := ""
+	if messageID != "" {
+		text = localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: messageID})
+	}
+
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, text)
+	callback.ShowAlert = messageID != ""
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+}
@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+)
+
+const (
+	chartWidth   = 640
+	chartHeight  = 320
+	chartPadding = 20
+	chartGap     = 8
+)
+
+var (
+	chartBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	chartAxis       = color.RGBA{R: 0x99, G: 0x99, B: 0x99, A: 0xff}
+	chartBar        = color.RGBA{R: 0x1f, G: 0x6f, B: 0xeb, A: 0xff}
+)
+
+// renderMonthsChart draws a bar chart of monthly counts as a PNG image, the exact numbers are left to the message text.
+func renderMonthsChart(months []*domain.MonthCount) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: chartBackground}, image.Point{}, draw.Src)
+
+	baseline := chartHeight - chartPadding
+	draw.Draw(
+		img,
+		image.Rect(chartPadding, baseline, chartWidth-chartPadding, baseline+1),
+		&image.Uniform{C: chartAxis},
+		image.Point{},
+		draw.Src,
+	)
+
+	maxCount := 0
+	for _, month := range months {
+		maxCount = max(maxCount, month.Count)
+	}
+
+	if len(months) > 0 && maxCount > 0 {
+		barWidth := (chartWidth - 2*chartPadding - (len(months)-1)*chartGap) / len(months)
+		for i, month := range months {
+			x := chartPadding + i*(barWidth+chartGap)
+			height := month.Count * (baseline - chartPadding) / maxCount
+			draw.Draw(
+				img,
+				image.Rect(x, baseline-height, x+barWidth, baseline),
+				&image.Uniform{C: chartBar},
+				image.Point{},
+				draw.Src,
+			)
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+const (
+	selectionKindCleanup = "cleanup"
+	cleanupDefaultMonths = 12
+)
+
+func (d *delivery) cleanupCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	months := cleanupDefaultMonths
+	if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
+		parsed, err := strconv.Atoi(arg)
+		if err != nil || parsed < 1 {
+			msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+				MessageID: "TelegramCleanupUsage",
+			}))
+			if _, err := d.bot.Send(msg); err != nil {
+				d.logger.Error("Error while sending a message!", zap.Error(err))
+			}
+			return nil
+		}
+		months = parsed
+	}
+
+	stale, err := d.service.StaleMaskedEmails(update.Message.From.ID, months)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	categories := []struct {
+		messageID    string
+		maskedEmails []*domain.MaskedEmail
+	}{
+		{"TelegramCleanupNeverUsed", stale.NeverUsed},
+		{"TelegramCleanupSilent", stale.Silent},
+		{"TelegramCleanupPending", stale.Pending},
+	}
+
+	sent := false
+	for _, category := range categories {
+		if len(category.maskedEmails) == 0 {
+			continue
+		}
+
+		text := localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: category.messageID,
+			TemplateData: map[string]interface{}{
+				"Count":  len(category.maskedEmails),
+				"Months": months,
+			},
+		})
+
+		if err := d.sendSelection(
+			localizer,
+			update.Message.Chat.ID,
+			update.Message.From.ID,
+			selectionKindCleanup,
+			text,
+			maskedEmailSelectionItems(category.maskedEmails),
+		); err != nil {
+			return err
+		}
+		sent = true
+	}
+
+	if !sent {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramCleanupEmpty",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+	}
+
+	return nil
+}
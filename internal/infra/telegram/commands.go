@@ -2,9 +2,9 @@ package telegram
 
 import (
 	"errors"
-	"regexp"
 	"strings"
 
+	"github.com/L11R/masked-email-bot/internal/domain"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"go.uber.org/zap"
@@ -51,8 +51,47 @@ func (d *delivery) anyOtherCommand(localizer *i18n.Localizer, update tgbotapi.Up
 	return nil
 }
 
+// emailMessage renders a freshly created masked email with the button keeping it from expiring.
+func emailMessage(localizer *i18n.Localizer, callbacks *callbackRegistry, owner int64, maskedEmail *domain.MaskedEmail) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramEmail",
+		TemplateData: map[string]interface{}{
+			"Email": maskedEmail.Email,
+		},
+	})
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		callbacks.button(
+			localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramEmailDoNotDeleteButton"}),
+			owner,
+			callbackEnable,
+			maskedEmail.ID,
+		),
+	))
+
+	return text, markup
+}
+
 func (d *delivery) generateMaskedEmail(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	// Our own addresses are looked up instead of being used as a prefix
+	if address := messageEmailAddress(update.Message); address != "" {
+		return d.sendMaskedEmailCard(localizer, update, address)
+	}
+
 	maskedEmail, err := d.service.GenerateMaskedEmail(update.Message.From.ID, update.Message.Text)
+	if errors.Is(err, domain.ErrDomainBlocked) {
+		d.sendDomainBlocked(localizer, update.Message)
+		return nil
+	}
+	if errors.Is(err, domain.ErrInvalidLabel) {
+		msg := tgbotapi.NewMessage(update.Message.From.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramInvalidLabel",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
 	if err != nil {
 		msg := tgbotapi.NewMessage(update.Message.From.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
 			MessageID: "TelegramError",
@@ -62,32 +101,17 @@ func (d *delivery) generateMaskedEmail(localizer *i18n.Localizer, update tgbotap
 		}
 		return err
 	}
-	maskedEmail.ID = "id:" + maskedEmail.ID
 
-	msg := tgbotapi.NewMessage(update.Message.From.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
-		MessageID: "TelegramEmail",
-		TemplateData: map[string]interface{}{
-			"Email": maskedEmail.Email,
-		},
-	}))
-	msg.ParseMode = "MarkdownV2"
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup([]tgbotapi.InlineKeyboardButton{{
-		Text:         localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramEmailDoNotDeleteButton"}),
-		CallbackData: &maskedEmail.ID,
-	}})
-	if _, err := d.bot.Send(msg); err != nil {
-		d.logger.Error("Error while sending a message!", zap.Error(err))
-	}
+	d.sendReply(d.newMaskedEmailMessage(localizer, update.Message.From.ID, update.Message.From.ID, maskedEmail), update.Message.From.ID, maskedEmail)
 
 	return nil
 }
 
-func (d *delivery) enableMaskedEmail(localizer *i18n.Localizer, update tgbotapi.Update) error {
-	dataParts := strings.Split(update.CallbackData(), ":")
-	if len(dataParts) < 2 {
-		return errors.New("invalid callback data")
+func (d *delivery) enableMaskedEmail(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
 	}
-	id := dataParts[1]
+	id := args[0]
 
 	if err := d.service.EnableMaskedEmail(update.CallbackQuery.From.ID, id); err != nil {
 		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
@@ -107,19 +131,30 @@ func (d *delivery) enableMaskedEmail(localizer *i18n.Localizer, update tgbotapi.
 		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
 	}
 
+	chatID := update.CallbackQuery.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.MessageID
+
 	// Remove inline keyboard and remove disclaimer from message
-	runes := []rune(update.CallbackQuery.Message.Text)
-	start := update.CallbackQuery.Message.Entities[0].Offset
-	end := update.CallbackQuery.Message.Entities[0].Offset + update.CallbackQuery.Message.Entities[0].Length
-	email := string(runes[start:end])
+	message, err := d.service.MaskedEmailMessage(update.CallbackQuery.From.ID, chatID, messageID)
+	if err != nil {
+		// Without the address the disclaimer has to stay, the button goes anyway
+		msg := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, d.callbacks.withoutAction(update.CallbackQuery.Message.ReplyMarkup, callbackEnable))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while editing a message!", zap.Error(err))
+		}
+		if errors.Is(err, domain.ErrNoMaskedEmailMessage) {
+			return nil
+		}
+		return err
+	}
 
 	msg := tgbotapi.NewEditMessageText(
-		update.CallbackQuery.Message.Chat.ID,
-		update.CallbackQuery.Message.MessageID,
+		chatID,
+		messageID,
 		localizer.MustLocalize(&i18n.LocalizeConfig{
 			MessageID: "TelegramEmailWithoutDisclaimer",
 			TemplateData: map[string]interface{}{
-				"Email": email,
+				"Email": message.Email,
 			},
 		}),
 	)
@@ -131,49 +166,127 @@ func (d *delivery) enableMaskedEmail(localizer *i18n.Localizer, update tgbotapi.
 	return nil
 }
 
+// answerInlineQueryWithEmail offers existing masked emails matching the query, and a new one for a prefix as the last result.
 func (d *delivery) answerInlineQueryWithEmail(localizer *i18n.Localizer, update tgbotapi.Update) error {
-	re := regexp.MustCompile(`[a-z0-9_]+`)
-	if re.FindString(update.InlineQuery.Query) != update.InlineQuery.Query || update.InlineQuery.Query == "" {
-		inlineConf := tgbotapi.InlineConfig{
-			InlineQueryID: update.InlineQuery.ID,
-			IsPersonal:    true,
-			CacheTime:     0,
-			Results:       []interface{}{},
-		}
-		if _, err := d.bot.Request(inlineConf); err != nil {
-			d.logger.Error("Error while answering inline query!", zap.Error(err))
-		}
+	if strings.HasPrefix(update.InlineQuery.Query, "#") {
+		return d.answerInlineQueryWithLabel(localizer, update)
+	}
 
-		return nil
+	// One place is left for the generate result
+	maskedEmails, err := d.service.SearchMaskedEmails(update.InlineQuery.From.ID, update.InlineQuery.Query, inlineResultsLimit-1)
+	if err != nil {
+		return d.answerInlineQuery(localizer, update, nil, err)
+	}
+
+	results := make([]interface{}, 0, len(maskedEmails)+1)
+	for _, maskedEmail := range maskedEmails {
+		results = append(results, inlineMaskedEmailResult(maskedEmail))
+	}
+
+	if result, ok := d.inlineGenerateResult(localizer, update.InlineQuery); ok {
+		results = append(results, result)
 	}
 
-	example := update.InlineQuery.Query + ".xxxxx@example.com"
-	result := tgbotapi.NewInlineQueryResultArticleMarkdownV2(update.InlineQuery.ID, example, "`"+example+"`")
-	update.InlineQuery.Query = "prefix:" + update.InlineQuery.Query
-	markup := tgbotapi.NewInlineKeyboardMarkup([]tgbotapi.InlineKeyboardButton{{
-		Text:         localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramInlineQueryGenerate"}),
-		CallbackData: &update.InlineQuery.Query,
-	}})
+	return d.answerInlineQuery(localizer, update, results, nil)
+}
+
+// inlineGenerateResult previews the address a prefix, a link or a domain would get, the button creates it.
+// The result is shared into other chats, so the button belongs to the user who made the query.
+func (d *delivery) inlineGenerateResult(localizer *i18n.Localizer, inlineQuery *tgbotapi.InlineQuery) (tgbotapi.InlineQueryResultArticle, bool) {
+	target := domain.ParseMaskedEmailTarget(inlineQuery.Query)
+	if target.Prefix == "" {
+		return tgbotapi.InlineQueryResultArticle{}, false
+	}
+
+	example := target.Prefix + ".xxxxx@example.com"
+	result := tgbotapi.NewInlineQueryResultArticleMarkdownV2(inlineQuery.ID, example, "`"+example+"`")
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		d.callbacks.expiringButton(
+			localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramInlineQueryGenerate"}),
+			inlineCallbackLifetime,
+			inlineQuery.From.ID,
+			callbackInline,
+			target.String(),
+		),
+	))
 	result.ReplyMarkup = &markup
 
+	if target.URL != nil {
+		result.Description = localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramInlineQueryGenerateForDomain",
+			TemplateData: map[string]interface{}{
+				"ForDomain": target.ForDomain(),
+			},
+		})
+	} else {
+		result.Description = localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramInlineQueryGenerateDescription"})
+	}
+
+	return result, true
+}
+
+// inlineMaskedEmailResult inserts the address into the chat when chosen.
+func inlineMaskedEmailResult(maskedEmail *domain.MaskedEmail) tgbotapi.InlineQueryResultArticle {
+	result := tgbotapi.NewInlineQueryResultArticleMarkdownV2(maskedEmail.ID, maskedEmail.Email, "`"+maskedEmail.Email+"`")
+
+	description := make([]string, 0, len(maskedEmail.Labels)+2)
+	if maskedEmail.ForDomain != "" {
+		description = append(description, maskedEmail.ForDomain)
+	}
+	if maskedEmail.Description != "" {
+		description = append(description, maskedEmail.Description)
+	}
+	for _, label := range maskedEmail.Labels {
+		description = append(description, "#"+label)
+	}
+	result.Description = strings.Join(description, " ")
+
+	return result
+}
+
+// answerInlineQuery sends the results, users without Fastmail connected get a button to the bot chat instead.
+func (d *delivery) answerInlineQuery(localizer *i18n.Localizer, update tgbotapi.Update, results []interface{}, err error) error {
 	inlineConf := tgbotapi.InlineConfig{
 		InlineQueryID: update.InlineQuery.ID,
 		IsPersonal:    true,
 		CacheTime:     0,
-		Results:       []interface{}{result},
+		Results:       results,
+	}
+	if inlineConf.Results == nil {
+		inlineConf.Results = []interface{}{}
 	}
+
+	switch {
+	case errors.Is(err, domain.ErrNoUser), errors.Is(err, domain.ErrNoToken):
+		inlineConf.SwitchPMText = localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramInlineQueryConnect"})
+		inlineConf.SwitchPMParameter = "connect"
+		err = nil
+	case errors.Is(err, domain.ErrInvalidLabel):
+		err = nil
+	}
+
 	if _, err := d.bot.Request(inlineConf); err != nil {
 		d.logger.Error("Error while answering inline query!", zap.Error(err))
 	}
 
-	return nil
+	return err
 }
 
-func (d *delivery) generateMaskedEmailWithInlineButton(localizer *i18n.Localizer, update tgbotapi.Update) error {
-	maskedEmail, err := d.service.Prefix(update.CallbackQuery.From.ID, strings.Split(update.CallbackData(), ":")[1])
+func (d *delivery) generateMaskedEmailWithInlineButton(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	// The same way as a direct message, so a link gets the address bound to the site
+	maskedEmail, err := d.service.GenerateMaskedEmail(update.CallbackQuery.From.ID, args[0])
 	if err != nil {
+		messageID := "TelegramError"
+		if errors.Is(err, domain.ErrDomainBlocked) {
+			messageID = "TelegramInlineQueryDomainBlocked"
+		}
+
 		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
-			MessageID: "TelegramError",
+			MessageID: messageID,
 		}))
 		callback.ShowAlert = true
 		if _, err := d.bot.Request(callback); err != nil {
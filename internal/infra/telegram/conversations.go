@@ -0,0 +1,190 @@
+package telegram
+
+import (
+	"errors"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+// conversationStep names what the bot is waiting for, the answer is dispatched to the handler registered for it.
+type conversationStep string
+
+const (
+	stepMailSubject     conversationStep = "mail.subject"
+	stepMailBody        conversationStep = "mail.body"
+	stepSelectionLabel  conversationStep = "selection.label"
+	stepCardLabel       conversationStep = "card.label"
+	stepCardDescription conversationStep = "card.description"
+)
+
+// conversationTimeout is how long the bot waits for the answer to a step.
+const conversationTimeout = time.Hour
+
+// conversationHandler handles the answer to a step, it either moves the conversation on or finishes it.
+// A conversation left as is waits for another answer, which is how invalid input is asked again.
+type conversationHandler func(localizer *i18n.Localizer, update tgbotapi.Update, conversation *domain.Conversation) error
+
+// conversationRegistry keeps one conversation per user and chat, a new one replaces the previous.
+type conversationRegistry struct {
+	logger *zap.Logger
+	store  domain.ConversationStore
+}
+
+func newConversationRegistry(logger *zap.Logger, store domain.ConversationStore) *conversationRegistry {
+	return &conversationRegistry{
+		logger: logger,
+		store:  store,
+	}
+}
+
+// save stores the conversation at its current step, giving the user another timeout to answer.
+func (r *conversationRegistry) save(conversation *domain.Conversation) error {
+	conversation.ExpiresAt = time.Now().UTC().Add(conversationTimeout)
+	return r.store.SaveConversation(conversation)
+}
+
+// current returns the conversation of the user in the chat, an expired one is returned along with ErrConversationExpired.
+func (r *conversationRegistry) current(chatID, telegramID int64) (*domain.Conversation, error) {
+	conversation, err := r.store.GetConversation(chatID, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forget dialogs nobody is going to finish anymore
+	if err := r.store.DeleteConversationsExpiredBefore(time.Now().UTC()); err != nil {
+		r.logger.Error("Error while purging expired conversations!", zap.Error(err))
+	}
+
+	if time.Now().After(conversation.ExpiresAt) {
+		return conversation, domain.ErrConversationExpired
+	}
+
+	return conversation, nil
+}
+
+func (r *conversationRegistry) finish(chatID, telegramID int64) error {
+	return r.store.DeleteConversation(chatID, telegramID)
+}
+
+func (d *delivery) conversationHandlers() map[conversationStep]conversationHandler {
+	return map[conversationStep]conversationHandler{
+		stepMailSubject:     d.mailSubjectStep,
+		stepMailBody:        d.mailBodyStep,
+		stepSelectionLabel:  d.selectionLabelStep,
+		stepCardLabel:       d.cardLabelStep,
+		stepCardDescription: d.cardDescriptionStep,
+	}
+}
+
+// forceReply opens the reply field for the prompt, only for the user mentioned or replied to in groups.
+func forceReply(placeholder string) tgbotapi.ForceReply {
+	return tgbotapi.ForceReply{
+		ForceReply:            true,
+		InputFieldPlaceholder: placeholder,
+		Selective:             true,
+	}
+}
+
+// prompt sends the question of the step and makes the next message of the user in the chat its answer.
+func (d *delivery) prompt(msg tgbotapi.MessageConfig, telegramID int64, step conversationStep, data map[string]string) error {
+	sent, err := d.bot.Send(msg)
+	if err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+		return domain.ErrTelegramInternal
+	}
+
+	return d.conversations.save(&domain.Conversation{
+		ChatID:          sent.Chat.ID,
+		TelegramID:      telegramID,
+		Step:            string(step),
+		Data:            data,
+		PromptMessageID: sent.MessageID,
+	})
+}
+
+// continueConversation passes the message to the step waiting for it, it reports false when there is no such step.
+func (d *delivery) continueConversation(localizer *i18n.Localizer, update tgbotapi.Update) (bool, error) {
+	message := update.Message
+	conversation, err := d.conversations.current(message.Chat.ID, message.From.ID)
+	if errors.Is(err, domain.ErrNoConversation) {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, domain.ErrConversationExpired) {
+		return true, err
+	}
+
+	repliesToPrompt := message.ReplyToMessage != nil && message.ReplyToMessage.MessageID == conversation.PromptMessageID
+	if errors.Is(err, domain.ErrConversationExpired) {
+		if err := d.conversations.finish(conversation.ChatID, conversation.TelegramID); err != nil {
+			return true, err
+		}
+
+		// A late answer gets told off, anything else is a regular message
+		if !repliesToPrompt {
+			return false, nil
+		}
+		d.replyText(localizer, message, "TelegramConversationExpired")
+		return true, nil
+	}
+
+	// Group members chat with each other, only answers to the prompt are taken
+	if !message.Chat.IsPrivate() && !repliesToPrompt {
+		return false, nil
+	}
+
+	if message.Text == "" {
+		d.replyText(localizer, message, "TelegramConversationTextExpected")
+		return true, nil
+	}
+
+	handler, ok := d.steps[conversationStep(conversation.Step)]
+	if !ok {
+		if err := d.conversations.finish(conversation.ChatID, conversation.TelegramID); err != nil {
+			return true, err
+		}
+		return true, errors.New("unknown conversation step: " + conversation.Step)
+	}
+
+	return true, handler(localizer, update, conversation)
+}
+
+func (d *delivery) cancelCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	messageID := "TelegramConversationCancelled"
+	_, err := d.conversations.current(update.Message.Chat.ID, update.Message.From.ID)
+	switch {
+	case errors.Is(err, domain.ErrNoConversation), errors.Is(err, domain.ErrConversationExpired):
+		messageID = "TelegramConversationNothingToCancel"
+	case err != nil:
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	if err := d.conversations.finish(update.Message.Chat.ID, update.Message.From.ID); err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: messageID,
+	}))
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
+
+// replyText replies to the message with a localized text.
+func (d *delivery) replyText(localizer *i18n.Localizer, message *tgbotapi.Message, messageID string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: messageID,
+	}))
+	msg.ReplyToMessageID = message.MessageID
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+}
@@ -6,18 +6,29 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"go.uber.org/zap"
-	"strings"
 )
 
 type delivery struct {
-	logger  *zap.Logger
-	config  *Config
-	bundle  *i18n.Bundle
-	bot     *tgbotapi.BotAPI
-	service domain.Service
+	logger    *zap.Logger
+	config    *Config
+	languages *languages
+	bot       *tgbotapi.BotAPI
+	callbacks *callbackRegistry
+	router    *router
+	// conversations track multi-step dialogs, steps handle the answers
+	conversations *conversationRegistry
+	steps         map[conversationStep]conversationHandler
+	service       domain.Service
 }
 
-func NewDelivery(logger *zap.Logger, config *Config, bundle *i18n.Bundle, service domain.Service) (domain.Delivery, error) {
+func NewDelivery(
+	logger *zap.Logger,
+	config *Config,
+	bundle *i18n.Bundle,
+	callbacks domain.CallbackStore,
+	conversations domain.ConversationStore,
+	service domain.Service,
+) (domain.Delivery, error) {
 	bot, err := tgbotapi.NewBotAPI(config.Token)
 	if err != nil {
 		return nil, err
@@ -25,13 +36,19 @@ func NewDelivery(logger *zap.Logger, config *Config, bundle *i18n.Bundle, servic
 
 	bot.Debug = config.Debug
 
-	return &delivery{
-		logger:  logger,
-		config:  config,
-		bundle:  bundle,
-		bot:     bot,
-		service: service,
-	}, nil
+	d := &delivery{
+		logger:        logger,
+		config:        config,
+		languages:     newLanguages(bundle),
+		bot:           bot,
+		callbacks:     newCallbackRegistry(logger, callbacks),
+		conversations: newConversationRegistry(logger, conversations),
+		service:       service,
+	}
+	d.router = d.routes()
+	d.steps = d.conversationHandlers()
+
+	return d, nil
 }
 
 func (d *delivery) ListenAndServe() error {
@@ -39,51 +56,82 @@ func (d *delivery) ListenAndServe() error {
 	updateConfig.Timeout = 30
 	updates := d.bot.GetUpdatesChan(updateConfig)
 
+	d.registerCommands()
+
 	for update := range updates {
-		switch {
-		case update.Message != nil:
-			localizer := i18n.NewLocalizer(d.bundle, update.Message.From.LanguageCode)
-			if update.Message.IsCommand() {
-				switch update.Message.Command() {
-				case "start":
-					if err := d.startCommand(localizer, update); err != nil {
-						d.logger.Error("Error while handling command!", zap.Error(err))
-					}
-					continue
-				default:
-					if err := d.anyOtherCommand(localizer, update); err != nil {
-						d.logger.Error("Error while handling command!", zap.Error(err))
-					}
-					continue
-				}
-			}
-			if err := d.generateMaskedEmail(localizer, update); err != nil {
-				d.logger.Error("Error while handling a link!", zap.Error(err))
-			}
-		case update.CallbackQuery != nil:
-			localizer := i18n.NewLocalizer(d.bundle, update.CallbackQuery.From.LanguageCode)
-			data := strings.Split(update.CallbackData(), ":")
-			switch data[0] {
-			case "id":
-				if err := d.enableMaskedEmail(localizer, update); err != nil {
-					d.logger.Error("Error while enabling a masked email!", zap.Error(err))
-				}
-			case "prefix":
-				if err := d.generateMaskedEmailWithInlineButton(localizer, update); err != nil {
-					d.logger.Error("Error while generating a masked email!", zap.Error(err))
-				}
-			}
-		case update.InlineQuery != nil:
-			localizer := i18n.NewLocalizer(d.bundle, update.InlineQuery.From.LanguageCode)
-			if err := d.answerInlineQueryWithEmail(localizer, update); err != nil {
-				d.logger.Error("Error while trying to answer inline query!", zap.Error(err))
-			}
-		}
+		d.router.serve(update)
 	}
 
 	return nil
 }
 
+// routes registers the handlers of the bot, the middleware runs in the listed order around each of them.
+func (d *delivery) routes() *router {
+	r := newRouter(d.recoverPanics, d.logUpdates, d.measureUpdates, d.localize, d.authorize)
+
+	r.command("start", d.startCommand, public(), described("TelegramCommandStart"))
+	r.command("help", d.helpCommand, public(), described("TelegramCommandHelp"))
+	r.command("cancel", d.cancelCommand, public(), described("TelegramCommandCancel"))
+	r.command("new", d.newCommand, described("TelegramCommandNew"))
+	r.command("list", d.listCommand, described("TelegramCommandList"))
+	r.command("services", d.servicesCommand, described("TelegramCommandServices"))
+	r.command("stats", d.statsCommand, described("TelegramCommandStats"))
+	r.command("inbox", d.inboxCommand, described("TelegramCommandInbox"))
+	r.command("send", d.sendCommand, described("TelegramCommandSend"))
+	r.command("unsubscribes", d.unsubscribesCommand, described("TelegramCommandUnsubscribes"))
+	r.command("forum", d.forumCommand, described("TelegramCommandForum"))
+	r.command("digest", d.digestCommand, described("TelegramCommandDigest"))
+	r.command("cleanup", d.cleanupCommand, described("TelegramCommandCleanup"))
+	r.command("bulk", d.bulkCommand, described("TelegramCommandBulk"))
+	r.command("block", d.blockCommand, described("TelegramCommandBlock"))
+	r.command("blocks", d.blocksCommand, described("TelegramCommandBlocks"))
+	r.command("export", d.exportCommand, described("TelegramCommandExport"))
+	r.command("disable", d.disableCommand, described("TelegramCommandDisable"))
+	r.command("delete", d.deleteCommand, described("TelegramCommandDelete"))
+	r.command("note", d.noteCommand, described("TelegramCommandNote"))
+	r.command("info", d.infoCommand, described("TelegramCommandInfo"))
+	r.command("language", d.languageCommand, described("TelegramCommandLanguage"))
+	r.unknownCommand(d.anyOtherCommand, public())
+
+	r.callbackQuery(d.handleCallback)
+	r.action(callbackEnable, d.enableMaskedEmail)
+	r.action(callbackInline, d.generateMaskedEmailWithInlineButton)
+	r.action(callbackReply, d.replyToMail)
+	r.action(callbackUnsubscribe, d.unsubscribe)
+	r.action(callbackSelection, d.selectionCallback)
+	r.action(callbackCard, d.maskedEmailCardCallback)
+	r.action(callbackRotate, d.rotateMaskedEmail)
+	r.action(callbackDisable, d.disableMaskedEmail)
+	r.action(callbackKeep, d.keepMaskedEmail)
+	r.action(callbackOverride, d.overrideBlockedDomain)
+	r.action(callbackUnblock, d.unblockDomain)
+	r.action(callbackLabel, d.labelCallback)
+	r.action(callbackUndo, d.undo)
+	r.action(callbackDescription, d.promptMaskedEmailDescription)
+	r.action(callbackLanguage, d.chooseLanguage)
+
+	r.inlineQuery(d.answerInlineQueryWithEmail)
+	r.documentMessage(d.importDocument, privateOnly())
+	r.textMessage(d.handleMessage)
+
+	return r
+}
+
+// handleMessage continues the conversation the message answers, or treats a private message as a link.
+func (d *delivery) handleMessage(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	handled, err := d.continueConversation(localizer, update)
+	if handled || err != nil {
+		return err
+	}
+
+	// Group members chat with each other, only private messages are treated as links
+	if !update.Message.Chat.IsPrivate() {
+		return nil
+	}
+
+	return d.generateMaskedEmail(localizer, update)
+}
+
 func (d *delivery) Shutdown(_ context.Context) error {
 	d.bot.StopReceivingUpdates()
 	return nil
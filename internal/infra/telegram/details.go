@@ -0,0 +1,301 @@
+package telegram
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+var emailAddressPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+var stateMessageIDs = map[domain.MaskedEmailState]string{
+	domain.MaskedEmailStatePending:  "TelegramStatePending",
+	domain.MaskedEmailStateEnabled:  "TelegramStateEnabled",
+	domain.MaskedEmailStateDisabled: "TelegramStateDisabled",
+	domain.MaskedEmailStateDeleted:  "TelegramStateDeleted",
+}
+
+var eventMessageIDs = map[domain.MaskedEmailEventType]string{
+	domain.MaskedEmailEventCreated:  "TelegramEventCreated",
+	domain.MaskedEmailEventEnabled:  "TelegramEventEnabled",
+	domain.MaskedEmailEventDisabled: "TelegramEventDisabled",
+	domain.MaskedEmailEventDeleted:  "TelegramEventDeleted",
+	domain.MaskedEmailEventRotated:  "TelegramEventRotated",
+}
+
+// messageEmailAddress returns the address a message is about: the whole text when pasted, the first one when forwarded.
+func messageEmailAddress(message *tgbotapi.Message) string {
+	text := strings.TrimSpace(message.Text)
+
+	if message.ForwardDate != 0 {
+		return emailAddressPattern.FindString(text + "\n" + message.Caption)
+	}
+
+	if emailAddressPattern.FindString(text) == text {
+		return text
+	}
+
+	return ""
+}
+
+// maskedEmailCard renders the details of a masked email with buttons for lifecycle operations allowed in its state.
+func (d *delivery) maskedEmailCard(localizer *i18n.Localizer, telegramID int64, details *domain.MaskedEmailDetails) (string, tgbotapi.InlineKeyboardMarkup) {
+	maskedEmail := details.MaskedEmail
+
+	history := make([]string, 0, len(details.History))
+	for _, event := range details.History {
+		messageID, ok := eventMessageIDs[event.Type]
+		if !ok {
+			continue
+		}
+
+		history = append(history, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: messageID,
+			TemplateData: map[string]interface{}{
+				"At":      event.CreatedAt.Format("2006-01-02 15:04"),
+				"Details": event.Details,
+			},
+		}))
+	}
+
+	state := string(maskedEmail.State)
+	if messageID, ok := stateMessageIDs[maskedEmail.State]; ok {
+		state = localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: messageID})
+	}
+
+	labels := make([]string, 0, len(maskedEmail.Labels))
+	for _, label := range maskedEmail.Labels {
+		labels = append(labels, "#"+label)
+	}
+
+	templateData := map[string]interface{}{
+		"Labels":      strings.Join(labels, " "),
+		"Email":       maskedEmail.Email,
+		"State":       state,
+		"ForDomain":   maskedEmail.ForDomain,
+		"Description": maskedEmail.Description,
+		"CreatedBy":   maskedEmail.CreatedBy,
+		"History":     history,
+	}
+	if !maskedEmail.CreatedAt.IsZero() {
+		templateData["CreatedAt"] = maskedEmail.CreatedAt.Format("2006-01-02 15:04")
+	}
+	if !maskedEmail.LastMessageAt.IsZero() {
+		templateData["LastMessageAt"] = maskedEmail.LastMessageAt.Format("2006-01-02 15:04")
+	}
+
+	text := localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID:    "TelegramMaskedEmailCard",
+		TemplateData: templateData,
+	})
+
+	button := func(messageID string, action callbackAction, args ...string) tgbotapi.InlineKeyboardButton {
+		return d.callbacks.button(localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: messageID}), telegramID, action, args...)
+	}
+
+	row := make([]tgbotapi.InlineKeyboardButton, 0, 3)
+	if maskedEmail.State != domain.MaskedEmailStateEnabled {
+		row = append(row, button("TelegramCardEnableButton", callbackCard, "enable", maskedEmail.ID))
+	}
+	if maskedEmail.State == domain.MaskedEmailStateEnabled || maskedEmail.State == domain.MaskedEmailStatePending {
+		row = append(row, button("TelegramCardDisableButton", callbackCard, "disable", maskedEmail.ID))
+	}
+	if maskedEmail.State != domain.MaskedEmailStateDeleted {
+		row = append(row, button("TelegramCardDeleteButton", callbackCard, "delete", maskedEmail.ID))
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{row}
+	if maskedEmail.State != domain.MaskedEmailStateDeleted {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			button("TelegramCardRotateButton", callbackRotate, maskedEmail.ID),
+		))
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		button("TelegramCardDescriptionButton", callbackDescription, maskedEmail.ID),
+		button("TelegramCardAddLabelButton", callbackLabel, "add", maskedEmail.ID),
+	))
+	for _, label := range maskedEmail.Labels {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			d.callbacks.button(
+				localizer.MustLocalize(&i18n.LocalizeConfig{
+					MessageID:    "TelegramCardRemoveLabelButton",
+					TemplateData: map[string]interface{}{"Label": label},
+				}),
+				telegramID,
+				callbackLabel,
+				"rm",
+				maskedEmail.ID,
+				label,
+			),
+		))
+	}
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (d *delivery) sendMaskedEmailCard(localizer *i18n.Localizer, update tgbotapi.Update, address string) error {
+	details, err := d.service.MaskedEmailDetailsByAddress(update.Message.From.ID, address)
+	if errors.Is(err, domain.ErrNoMaskedEmail) {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramMaskedEmailNotFound",
+		}))
+		msg.ReplyToMessageID = update.Message.MessageID
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	text, markup := d.maskedEmailCard(localizer, update.Message.From.ID, details)
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+	msg.DisableWebPagePreview = true
+	msg.ReplyMarkup = markup
+	d.sendReply(msg, update.Message.From.ID, details.MaskedEmail)
+
+	return nil
+}
+
+// promptCard asks a question about the card's masked email, the answer is bound to the card to render it again.
+func (d *delivery) promptCard(localizer *i18n.Localizer, update tgbotapi.Update, messageID, placeholder string, step conversationStep, id string) error {
+	message := update.CallbackQuery.Message
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: messageID,
+	}))
+	msg.ReplyToMessageID = message.MessageID
+	msg.ReplyMarkup = forceReply(placeholder)
+
+	return d.prompt(msg, update.CallbackQuery.From.ID, step, map[string]string{
+		"id":         id,
+		"message_id": strconv.Itoa(message.MessageID),
+	})
+}
+
+// conversationCard returns the masked email and the card message the conversation is about.
+func conversationCard(conversation *domain.Conversation) (string, int, error) {
+	messageID, err := strconv.Atoi(conversation.Data["message_id"])
+	if err != nil {
+		return "", 0, err
+	}
+
+	return conversation.Data["id"], messageID, nil
+}
+
+func (d *delivery) promptMaskedEmailDescription(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+	d.answerCallbackAlert(localizer, update, "")
+
+	return d.promptCard(
+		localizer,
+		update,
+		"TelegramCardDescriptionPrompt",
+		localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramCardDescriptionPlaceholder"}),
+		stepCardDescription,
+		args[0],
+	)
+}
+
+// cardDescriptionStep saves the answer as the description and renders the card again.
+func (d *delivery) cardDescriptionStep(localizer *i18n.Localizer, update tgbotapi.Update, conversation *domain.Conversation) error {
+	id, messageID, err := conversationCard(conversation)
+	if err != nil {
+		return err
+	}
+
+	result, err := d.service.SetMaskedEmailDescription(conversation.TelegramID, id, strings.TrimSpace(update.Message.Text))
+	if err == nil && len(result.Failed) > 0 {
+		err = domain.ErrFastmailInternal
+	}
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	if err := d.conversations.finish(conversation.ChatID, conversation.TelegramID); err != nil {
+		return err
+	}
+
+	return d.refreshMaskedEmailCard(localizer, conversation.TelegramID, conversation.ChatID, messageID, id, result.UndoID)
+}
+
+var cardActionStates = map[string]domain.MaskedEmailState{
+	"enable":  domain.MaskedEmailStateEnabled,
+	"disable": domain.MaskedEmailStateDisabled,
+	"delete":  domain.MaskedEmailStateDeleted,
+}
+
+func (d *delivery) maskedEmailCardCallback(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 2 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	state, ok := cardActionStates[args[0]]
+	if !ok {
+		return d.invalidCallback(localizer, update)
+	}
+	id := args[1]
+
+	result, err := d.service.SetMaskedEmailsState(update.CallbackQuery.From.ID, []string{id}, state)
+	if err == nil && len(result.Failed) > 0 {
+		err = domain.ErrFastmailInternal
+	}
+	if err != nil {
+		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramError",
+		}))
+		callback.ShowAlert = true
+		if _, err := d.bot.Request(callback); err != nil {
+			d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+		}
+		return err
+	}
+
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+
+	return d.refreshMaskedEmailCard(
+		localizer,
+		update.CallbackQuery.From.ID,
+		update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		id,
+		result.UndoID,
+	)
+}
+
+// refreshMaskedEmailCard renders the card again after a change, with the undo button when the change can be undone.
+func (d *delivery) refreshMaskedEmailCard(localizer *i18n.Localizer, telegramID, chatID int64, messageID int, id string, undoID int64) error {
+	details, err := d.service.MaskedEmailDetails(telegramID, id)
+	if err != nil {
+		return err
+	}
+
+	text, markup := d.maskedEmailCard(localizer, telegramID, details)
+	if undoID != 0 {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			d.undoButton(localizer, telegramID, undoID, id),
+		))
+	}
+	msg := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, markup)
+	msg.DisableWebPagePreview = true
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+	}
+
+	return nil
+}
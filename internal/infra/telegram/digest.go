@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+func digestTemplateData(digest *domain.Digest) map[string]interface{} {
+	expiring := make([]map[string]interface{}, 0, len(digest.Expiring))
+	for _, maskedEmail := range digest.Expiring {
+		expiring = append(expiring, map[string]interface{}{
+			"Email":     maskedEmail.Email,
+			"ExpiresAt": maskedEmail.CreatedAt.Add(domain.PendingLifetime).In(digest.Location).Format("2006-01-02 15:04"),
+		})
+	}
+
+	return map[string]interface{}{
+		"Weekly":       digest.Schedule == domain.DigestScheduleWeekly,
+		"From":         digest.From.In(digest.Location).Format("2006-01-02 15:04"),
+		"To":           digest.To.In(digest.Location).Format("2006-01-02 15:04"),
+		"Created":      digest.Created,
+		"FirstMail":    digest.FirstMail,
+		"Expiring":     expiring,
+		"NoisySenders": digest.NoisySenders,
+		"Leaks":        digest.Leaks,
+	}
+}
+
+func (a *adapter) SendDigest(telegramID int64, languageCode string, digest *domain.Digest) error {
+	localizer := a.languages.localizer(languageCode)
+
+	msg := tgbotapi.NewMessage(telegramID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID:    "TelegramDigest",
+		TemplateData: digestTemplateData(digest),
+	}))
+	msg.DisableWebPagePreview = true
+
+	if _, err := a.bot.Send(msg); err != nil {
+		a.logger.Error("Error while sending a digest!", zap.Error(err))
+		return domain.ErrTelegramInternal
+	}
+
+	return nil
+}
+
+// parseDigestArgs parses "<off|daily|weekly> [hour] [timezone]" on top of the current settings.
+func parseDigestArgs(args []string, settings domain.DigestSettings) (*domain.DigestSettings, error) {
+	settings.Schedule = domain.DigestSchedule(strings.ToLower(args[0]))
+
+	if len(args) > 1 {
+		hour, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, domain.ErrInvalidDigestSettings
+		}
+		settings.Hour = hour
+	}
+
+	if len(args) > 2 {
+		settings.Timezone = args[2]
+	}
+
+	if len(args) > 3 {
+		return nil, domain.ErrInvalidDigestSettings
+	}
+
+	return &settings, nil
+}
+
+func (d *delivery) digestCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	args := strings.Fields(update.Message.CommandArguments())
+
+	if len(args) == 1 && args[0] == "now" {
+		if err := d.service.SendDigest(update.Message.From.ID); err != nil {
+			d.sendError(localizer, update.Message.Chat.ID, err)
+			return err
+		}
+		return nil
+	}
+
+	settings, err := d.service.DigestSettings(update.Message.From.ID)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	messageID := "TelegramDigestSettings"
+	if len(args) > 0 {
+		// The usage shows the stored settings, so the parsed ones replace them only once they are saved
+		updated, err := parseDigestArgs(args, *settings)
+		if err == nil {
+			err = d.service.UpdateDigestSettings(update.Message.From.ID, updated)
+		}
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidDigestSettings):
+			messageID = "TelegramDigestUsage"
+		case err != nil:
+			d.sendError(localizer, update.Message.Chat.ID, err)
+			return err
+		default:
+			messageID = "TelegramDigestUpdated"
+			settings = updated
+		}
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: messageID,
+		TemplateData: map[string]interface{}{
+			"Schedule": settings.Schedule,
+			"Hour":     settings.Hour,
+			"Timezone": settings.Timezone,
+		},
+	}))
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
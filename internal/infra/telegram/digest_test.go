@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+)
+
+func TestParseDigestArgs(t *testing.T) {
+	stored := domain.DigestSettings{
+		Schedule: domain.DigestScheduleOff,
+		Timezone: "UTC",
+		Hour:     9,
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    *domain.DigestSettings
+		wantErr error
+	}{
+		{
+			"schedule only",
+			[]string{"daily"},
+			&domain.DigestSettings{Schedule: domain.DigestScheduleDaily, Timezone: "UTC", Hour: 9},
+			nil,
+		},
+		{
+			"schedule case",
+			[]string{"Weekly"},
+			&domain.DigestSettings{Schedule: domain.DigestScheduleWeekly, Timezone: "UTC", Hour: 9},
+			nil,
+		},
+		{
+			"hour",
+			[]string{"daily", "18"},
+			&domain.DigestSettings{Schedule: domain.DigestScheduleDaily, Timezone: "UTC", Hour: 18},
+			nil,
+		},
+		{
+			"timezone",
+			[]string{"weekly", "7", "Europe/Moscow"},
+			&domain.DigestSettings{Schedule: domain.DigestScheduleWeekly, Timezone: "Europe/Moscow", Hour: 7},
+			nil,
+		},
+		// Values are checked when the settings are saved, parsing only fills them in
+		{
+			"unknown timezone",
+			[]string{"daily", "7", "Mars/Olympus"},
+			&domain.DigestSettings{Schedule: domain.DigestScheduleDaily, Timezone: "Mars/Olympus", Hour: 7},
+			nil,
+		},
+		{"hour not a number", []string{"daily", "abc"}, nil, domain.ErrInvalidDigestSettings},
+		{"too many", []string{"daily", "7", "UTC", "extra"}, nil, domain.ErrInvalidDigestSettings},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDigestArgs(tt.args, stored)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseDigestArgs(%q) error = %v, want %v", tt.args, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDigestArgs(%q) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+
+	if stored.Schedule != domain.DigestScheduleOff || stored.Hour != 9 {
+		t.Errorf("parseDigestArgs changed the stored settings to %+v", stored)
+	}
+}
@@ -0,0 +1,79 @@
+package telegram
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+func (d *delivery) exportCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	// The format goes first and is optional, the rest are the same filters /list takes
+	format := domain.ExportFormatCSV
+	arguments := update.Message.CommandArguments()
+	if fields := strings.Fields(strings.ToLower(arguments)); len(fields) > 0 &&
+		slices.Contains(domain.ExportFormats, domain.ExportFormat(fields[0])) {
+		format = domain.ExportFormat(fields[0])
+		arguments = strings.Join(fields[1:], " ")
+	}
+
+	filter, ok := parseMaskedEmailFilter(arguments)
+	if !ok {
+		formats := make([]string, 0, len(domain.ExportFormats))
+		for _, format := range domain.ExportFormats {
+			formats = append(formats, string(format))
+		}
+
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID:    "TelegramExportUsage",
+			TemplateData: map[string]interface{}{"Formats": strings.Join(formats, "|")},
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	export, err := d.service.ExportMaskedEmails(update.Message.From.ID, format, filter)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	if len(export.MaskedEmails) == 0 {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramExportEmpty",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	// The file is uploaded while it's being encoded
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := export.WriteTo(pw)
+		pw.CloseWithError(err)
+	}()
+
+	doc := tgbotapi.NewDocument(update.Message.Chat.ID, tgbotapi.FileReader{Name: export.Filename(), Reader: pr})
+	doc.Caption = localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID:    "TelegramExport",
+		TemplateData: map[string]interface{}{"Count": len(export.MaskedEmails)},
+	})
+	_, err = d.bot.Send(doc)
+	// Unblock the encoder if the upload stopped reading early
+	pr.Close()
+	if err != nil {
+		d.logger.Error("Error while sending a document!", zap.Error(err))
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return domain.ErrTelegramInternal
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package telegram
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+func (d *delivery) forumCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	var messageID string
+	var err error
+
+	switch {
+	case strings.TrimSpace(update.Message.CommandArguments()) == "off":
+		err = d.service.DisconnectForum(update.Message.From.ID)
+		messageID = "TelegramForumDisconnected"
+	case update.Message.Chat.IsSuperGroup():
+		err = d.service.ConnectForum(update.Message.From.ID, update.Message.Chat.ID)
+		messageID = "TelegramForumConnected"
+	default:
+		messageID = "TelegramForumUsage"
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrNotForum):
+		messageID = "TelegramForumNotForum"
+	case errors.Is(err, domain.ErrNoUser):
+		messageID = "TelegramForumNoUser"
+	case err != nil:
+		messageID = "TelegramError"
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: messageID,
+	}))
+	// Replying keeps the answer in the topic the command was sent from
+	msg.ReplyToMessageID = update.Message.MessageID
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return err
+}
@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+// botCommands returns the described commands of the router in the language of the localizer.
+func (d *delivery) botCommands(localizer *i18n.Localizer) []tgbotapi.BotCommand {
+	commands := make([]tgbotapi.BotCommand, 0, len(d.router.menu))
+	for _, name := range d.router.menu {
+		commands = append(commands, tgbotapi.BotCommand{
+			Command:     name,
+			Description: localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: d.router.commands[name].description}),
+		})
+	}
+
+	return commands
+}
+
+// registerCommands publishes the command menu in every language of the bundle, Telegram shows the one of the user.
+func (d *delivery) registerCommands() {
+	for _, tag := range d.languages.tags() {
+		base, _ := tag.Base()
+		msg := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(
+			tgbotapi.NewBotCommandScopeDefault(),
+			base.String(),
+			d.botCommands(d.languages.localizer(tag.String()))...,
+		)
+		if _, err := d.bot.Request(msg); err != nil {
+			d.logger.Error("Error while registering bot commands!", zap.String("language", base.String()), zap.Error(err))
+		}
+	}
+
+	// Users of other languages get the menu in the default language of the bundle
+	msg := tgbotapi.NewSetMyCommands(d.botCommands(d.languages.localizer())...)
+	if _, err := d.bot.Request(msg); err != nil {
+		d.logger.Error("Error while registering bot commands!", zap.Error(err))
+	}
+}
+
+func (d *delivery) helpCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	lines := make([]string, 0, len(d.router.menu))
+	for _, command := range d.botCommands(localizer) {
+		lines = append(lines, "/"+command.Command+" — "+command.Description)
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramHelp",
+		TemplateData: map[string]interface{}{
+			"Commands": strings.Join(lines, "\n"),
+		},
+	}))
+	msg.DisableWebPagePreview = true
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+const selectionKindImport = "import"
+
+const (
+	// importFileSizeLimit refuses files no password manager export of a person would reach.
+	importFileSizeLimit   = 1 << 20
+	importDownloadTimeout = 30 * time.Second
+	// messageTextLimit is the longest text Telegram accepts in a message.
+	messageTextLimit = 4096
+)
+
+func (d *delivery) importDocument(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	document := update.Message.Document
+	if document.FileSize > importFileSizeLimit {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramImportTooLarge",
+		}))
+		msg.ReplyToMessageID = update.Message.MessageID
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	fileURL, err := d.bot.GetFileDirectURL(document.FileID)
+	if err != nil {
+		d.logger.Error("Error while getting a file URL!", zap.Error(err))
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return domain.ErrTelegramInternal
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), importDownloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		d.logger.Error("Error while creating a new HTTP request!", zap.Error(err))
+		return domain.ErrTelegramInternal
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		d.logger.Error("Error while downloading a file!", zap.Error(err))
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return domain.ErrTelegramInternal
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		d.logger.Error("Wrong status code!", zap.Int("status_code", resp.StatusCode))
+		d.sendError(localizer, update.Message.Chat.ID, domain.ErrTelegramInternal)
+		return domain.ErrTelegramInternal
+	}
+
+	candidates, err := d.service.ImportCandidates(update.Message.From.ID, resp.Body)
+	if errors.Is(err, domain.ErrInvalidImport) {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramImportEmpty",
+		}))
+		msg.ReplyToMessageID = update.Message.MessageID
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	// Services that already have an address or are blocked are shown, but left for the user to pick
+	existing, blocked := 0, 0
+	items := make([]*domain.SelectionItem, 0, len(candidates))
+	for _, candidate := range candidates {
+		label := candidate.Host
+		switch {
+		case candidate.Blocked:
+			blocked++
+			label = "⛔ " + label
+		case candidate.Existing > 0:
+			existing++
+			label = localizer.MustLocalize(&i18n.LocalizeConfig{
+				MessageID: "TelegramImportExistingItem",
+				TemplateData: map[string]interface{}{
+					"Host":     candidate.Host,
+					"Existing": candidate.Existing,
+				},
+			})
+		}
+
+		items = append(items, &domain.SelectionItem{
+			ID:       candidate.URL,
+			Label:    label,
+			Selected: !candidate.Blocked && candidate.Existing == 0,
+		})
+	}
+
+	return d.sendSelection(
+		localizer,
+		update.Message.Chat.ID,
+		update.Message.From.ID,
+		selectionKindImport,
+		localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramImportPreview",
+			TemplateData: map[string]interface{}{
+				"Count":    len(candidates),
+				"Existing": existing,
+				"Blocked":  blocked,
+			},
+		}),
+		items,
+	)
+}
+
+func (d *delivery) importSelection(localizer *i18n.Localizer, update tgbotapi.Update, selection *domain.Selection, ids []string) error {
+	// Creating a lot of addresses takes a while, so say it's going on
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramImportInProgress",
+	}))
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+
+	// Drop the keyboard right away, so the same services can't be created twice
+	if err := d.service.DeleteSelection(selection.ChatID, selection.MessageID); err != nil {
+		return err
+	}
+	edit := tgbotapi.NewEditMessageReplyMarkup(selection.ChatID, selection.MessageID, tgbotapi.NewInlineKeyboardMarkup())
+	if _, err := d.bot.Send(edit); err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+	}
+
+	imported, err := d.service.ImportMaskedEmails(update.CallbackQuery.From.ID, ids)
+	if err != nil {
+		d.sendError(localizer, selection.ChatID, err)
+		return err
+	}
+
+	created := 0
+	lines := make([]string, 0, len(imported))
+	for _, item := range imported {
+		if item.MaskedEmail == nil {
+			lines = append(lines, "❌ "+item.Host)
+			continue
+		}
+
+		created++
+		lines = append(lines, "✅ "+item.Host+" → "+item.MaskedEmail.Email)
+	}
+
+	header := localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramImportReport",
+		TemplateData: map[string]interface{}{
+			"Created": created,
+			"Failed":  len(imported) - created,
+		},
+	})
+
+	// The report maps every service, so it goes on in more messages instead of being cut
+	chunks := splitMessageText(header, lines)
+	msg := tgbotapi.NewEditMessageText(selection.ChatID, selection.MessageID, chunks[0])
+	msg.DisableWebPagePreview = true
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+	}
+
+	for _, chunk := range chunks[1:] {
+		msg := tgbotapi.NewMessage(selection.ChatID, chunk)
+		msg.DisableWebPagePreview = true
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// splitMessageText joins the header and lines into texts that each fit into a message.
+func splitMessageText(header string, lines []string) []string {
+	chunks := make([]string, 0, 1)
+	var b strings.Builder
+	b.WriteString(header)
+	for _, line := range lines {
+		if b.Len()+len(line)+1 > messageTextLimit {
+			chunks = append(chunks, b.String())
+			b.Reset()
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line)
+	}
+
+	return append(chunks, b.String())
+}
@@ -0,0 +1,190 @@
+package telegram
+
+import (
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+// listLimit keeps the list within a single message.
+const listLimit = 40
+
+// inlineResultsLimit is the most results Telegram accepts for an inline query.
+const inlineResultsLimit = 50
+
+var listStates = map[string]domain.MaskedEmailState{
+	"pending":  domain.MaskedEmailStatePending,
+	"enabled":  domain.MaskedEmailStateEnabled,
+	"disabled": domain.MaskedEmailStateDisabled,
+	"deleted":  domain.MaskedEmailStateDeleted,
+}
+
+// parseMaskedEmailFilter reads "#label" and state words from command arguments.
+func parseMaskedEmailFilter(arguments string) (*domain.MaskedEmailFilter, bool) {
+	filter := &domain.MaskedEmailFilter{}
+	for _, arg := range strings.Fields(strings.ToLower(arguments)) {
+		if strings.HasPrefix(arg, "#") {
+			if filter.Label != "" {
+				return nil, false
+			}
+			filter.Label = arg
+			continue
+		}
+
+		state, ok := listStates[arg]
+		if !ok {
+			return nil, false
+		}
+		filter.States = append(filter.States, state)
+	}
+
+	return filter, true
+}
+
+func (d *delivery) listCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	filter, ok := parseMaskedEmailFilter(update.Message.CommandArguments())
+	if !ok {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramListUsage",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	maskedEmails, err := d.service.MaskedEmails(update.Message.From.ID, filter)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	labels, err := d.service.Labels(update.Message.From.ID)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	items := make([]map[string]interface{}, 0, min(len(maskedEmails), listLimit))
+	for _, maskedEmail := range maskedEmails[:min(len(maskedEmails), listLimit)] {
+		item := map[string]interface{}{
+			"Email":     maskedEmail.Email,
+			"ForDomain": maskedEmail.ForDomain,
+			"Labels":    maskedEmail.Labels,
+		}
+		if maskedEmail.State != domain.MaskedEmailStateEnabled {
+			item["State"] = localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: stateMessageIDs[maskedEmail.State]})
+		}
+		items = append(items, item)
+	}
+
+	messageID := "TelegramList"
+	if len(maskedEmails) == 0 {
+		messageID = "TelegramListEmpty"
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: messageID,
+		TemplateData: map[string]interface{}{
+			"Count":        len(maskedEmails),
+			"MaskedEmails": items,
+			"Hidden":       len(maskedEmails) - len(items),
+			"Labels":       labels,
+		},
+	}))
+	msg.DisableWebPagePreview = true
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
+
+func (d *delivery) labelCallback(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 2 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	switch args[0] {
+	case "add":
+		return d.promptMaskedEmailLabel(localizer, update, args[1])
+	case "rm":
+		if len(args) < 3 {
+			return d.invalidCallback(localizer, update)
+		}
+
+		if err := d.service.RemoveMaskedEmailLabel(update.CallbackQuery.From.ID, args[1], args[2]); err != nil {
+			callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+				MessageID: "TelegramError",
+			}))
+			callback.ShowAlert = true
+			if _, err := d.bot.Request(callback); err != nil {
+				d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+			}
+			return err
+		}
+
+		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
+		if _, err := d.bot.Request(callback); err != nil {
+			d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+		}
+
+		return d.refreshMaskedEmailCard(
+			localizer,
+			update.CallbackQuery.From.ID,
+			update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			args[1],
+			0,
+		)
+	default:
+		return d.invalidCallback(localizer, update)
+	}
+}
+
+// promptMaskedEmailLabel asks for a label for the card's masked email, the answer goes to cardLabelStep.
+func (d *delivery) promptMaskedEmailLabel(localizer *i18n.Localizer, update tgbotapi.Update, id string) error {
+	d.answerCallbackAlert(localizer, update, "")
+
+	return d.promptCard(localizer, update, "TelegramCardLabelPrompt", "#label", stepCardLabel, id)
+}
+
+// cardLabelStep adds the label from the answer and renders the card again.
+func (d *delivery) cardLabelStep(localizer *i18n.Localizer, update tgbotapi.Update, conversation *domain.Conversation) error {
+	id, messageID, err := conversationCard(conversation)
+	if err != nil {
+		return err
+	}
+
+	result, err := d.service.AddMaskedEmailsLabel(conversation.TelegramID, []string{id}, update.Message.Text)
+	if err != nil {
+		return d.replyLabelError(localizer, update, err)
+	}
+
+	if err := d.conversations.finish(conversation.ChatID, conversation.TelegramID); err != nil {
+		return err
+	}
+
+	return d.refreshMaskedEmailCard(localizer, conversation.TelegramID, conversation.ChatID, messageID, id, result.UndoID)
+}
+
+// answerInlineQueryWithLabel offers active masked emails with the label, so they can be shared in any chat.
+func (d *delivery) answerInlineQueryWithLabel(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	maskedEmails, err := d.service.MaskedEmails(update.InlineQuery.From.ID, &domain.MaskedEmailFilter{
+		States: []domain.MaskedEmailState{domain.MaskedEmailStateEnabled, domain.MaskedEmailStatePending},
+		Label:  update.InlineQuery.Query,
+	})
+	if err != nil {
+		return d.answerInlineQuery(localizer, update, nil, err)
+	}
+
+	results := make([]interface{}, 0, min(len(maskedEmails), inlineResultsLimit))
+	for _, maskedEmail := range maskedEmails[:min(len(maskedEmails), inlineResultsLimit)] {
+		results = append(results, inlineMaskedEmailResult(maskedEmail))
+	}
+
+	return d.answerInlineQuery(localizer, update, results, nil)
+}
@@ -0,0 +1,139 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// languages are the locales the bundle has been loaded with, users get the one closest to what they prefer.
+type languages struct {
+	bundle  *i18n.Bundle
+	matcher language.Matcher
+}
+
+func newLanguages(bundle *i18n.Bundle) *languages {
+	return &languages{
+		bundle:  bundle,
+		matcher: language.NewMatcher(bundle.LanguageTags()),
+	}
+}
+
+// tags returns the languages of the bundle, the default one first.
+func (l *languages) tags() []language.Tag {
+	return l.bundle.LanguageTags()
+}
+
+// match returns the language of the bundle closest to the language codes, the most preferred first.
+// Codes that don't parse are skipped, the default language is returned when nothing matches.
+func (l *languages) match(languageCodes ...string) language.Tag {
+	preferred := make([]language.Tag, 0, len(languageCodes))
+	for _, languageCode := range languageCodes {
+		tag, err := language.Parse(languageCode)
+		if err != nil {
+			continue
+		}
+		preferred = append(preferred, tag)
+	}
+
+	// The matched tag may carry extensions of the preferred one, the index points to the tag of the bundle as is
+	_, index, confidence := l.matcher.Match(preferred...)
+	if confidence == language.No {
+		// A merely related language, like Russian for Ukrainian, is no better guess than the default one
+		return l.tags()[0]
+	}
+	return l.tags()[index]
+}
+
+func (l *languages) localizer(languageCodes ...string) *i18n.Localizer {
+	return i18n.NewLocalizer(l.bundle, l.match(languageCodes...).String())
+}
+
+// userLanguage returns the language of the sender, the one chosen with /language goes before the one of the Telegram client.
+func (d *delivery) userLanguage(sender *tgbotapi.User) language.Tag {
+	if sender == nil {
+		return d.languages.match()
+	}
+
+	languageCode, err := d.service.LanguageCode(sender.ID)
+	if err != nil {
+		// The client language still makes a better answer than none
+		d.logger.Error("Error while getting a language of the user!", zap.Error(err))
+	}
+
+	return d.languages.match(languageCode, sender.LanguageCode)
+}
+
+func (d *delivery) languageCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	current := d.userLanguage(update.Message.From)
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(d.languages.tags()))
+	for _, tag := range d.languages.tags() {
+		text := cases.Title(tag).String(display.Self.Name(tag))
+		if tag == current {
+			text = "✅ " + text
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			d.callbacks.button(text, update.Message.From.ID, callbackLanguage, tag.String()),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramLanguage",
+	}))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
+
+// chooseLanguage stores the picked language and answers in it right away.
+func (d *delivery) chooseLanguage(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	tag := d.languages.match(args[0])
+	if tag.String() != args[0] {
+		return d.invalidCallback(localizer, update)
+	}
+
+	if err := d.service.ChooseLanguageCode(update.CallbackQuery.From.ID, tag.String()); err != nil {
+		d.answerCallbackAlert(localizer, update, "TelegramError")
+		return err
+	}
+
+	d.answerCallbackAlert(localizer, update, "")
+
+	localizer = d.languages.localizer(tag.String())
+	msg := tgbotapi.NewEditMessageText(
+		update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID:    "TelegramLanguageChosen",
+			TemplateData: map[string]interface{}{"Language": display.Self.Name(tag)},
+		}),
+	)
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+	}
+
+	// Menus of the bot follow the client language, the private chat gets its own one in the chosen language
+	if update.CallbackQuery.Message.Chat.IsPrivate() {
+		commands := tgbotapi.NewSetMyCommandsWithScope(
+			tgbotapi.NewBotCommandScopeChat(update.CallbackQuery.Message.Chat.ID),
+			d.botCommands(localizer)...,
+		)
+		if _, err := d.bot.Request(commands); err != nil {
+			d.logger.Error("Error while registering bot commands!", zap.Error(err))
+		}
+	}
+
+	return nil
+}
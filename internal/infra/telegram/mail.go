@@ -0,0 +1,249 @@
+package telegram
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+// errorMessageID returns the message explaining the error to the user.
+func errorMessageID(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidAddress):
+		return "TelegramInvalidAddress"
+	case errors.Is(err, domain.ErrInvalidLabel):
+		return "TelegramInvalidLabel"
+	case errors.Is(err, domain.ErrInvalidDomain):
+		return "TelegramInvalidDomain"
+	case errors.Is(err, domain.ErrInvalidPrefix):
+		return "TelegramInvalidPrefix"
+	case errors.Is(err, domain.ErrFastmailInsufficientScope):
+		return "TelegramInsufficientScope"
+	}
+
+	return "TelegramError"
+}
+
+func (d *delivery) sendError(localizer *i18n.Localizer, chatID int64, err error) {
+	msg := tgbotapi.NewMessage(chatID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: errorMessageID(err),
+	}))
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+}
+
+func (d *delivery) inboxCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	args := strings.Fields(update.Message.CommandArguments())
+	if len(args) != 1 {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramInboxUsage",
+		}))
+		msg.ParseMode = "MarkdownV2"
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	mails, err := d.service.Mails(update.Message.From.ID, args[0])
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	if len(mails) == 0 {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramInboxEmpty",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	for _, mail := range mails {
+		text, markup := mailMessage(localizer, d.callbacks, update.Message.From.ID, mail)
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+		msg.ParseMode = "MarkdownV2"
+		msg.ReplyMarkup = markup
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// mailMessage renders a received mail preview with its action buttons.
+func mailMessage(localizer *i18n.Localizer, callbacks *callbackRegistry, owner int64, mail *domain.Mail) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramMail",
+		TemplateData: map[string]interface{}{
+			"From":       tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, mail.From),
+			"Subject":    tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, mail.Subject),
+			"Preview":    tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, mail.Preview),
+			"ReceivedAt": tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, mail.ReceivedAt.Format("2006-01-02 15:04")),
+		},
+	})
+
+	buttons := []tgbotapi.InlineKeyboardButton{
+		callbacks.button(
+			localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramMailReplyButton"}),
+			owner,
+			callbackReply,
+			mail.ID,
+		),
+	}
+	if len(mail.ListUnsubscribe) > 0 {
+		buttons = append(buttons, callbacks.button(
+			localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramMailUnsubscribeButton"}),
+			owner,
+			callbackUnsubscribe,
+			mail.ID,
+		))
+	}
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(buttons)
+}
+
+// draftData keeps the draft in the conversation while it's being composed.
+func draftData(draft *domain.Draft) map[string]string {
+	return map[string]string{
+		"from":        draft.From,
+		"to":          draft.To,
+		"subject":     draft.Subject,
+		"in_reply_to": strings.Join(draft.InReplyTo, " "),
+		"refs":        strings.Join(draft.References, " "),
+	}
+}
+
+func conversationDraft(conversation *domain.Conversation) *domain.Draft {
+	return &domain.Draft{
+		TelegramID: conversation.TelegramID,
+		From:       conversation.Data["from"],
+		To:         conversation.Data["to"],
+		Subject:    conversation.Data["subject"],
+		InReplyTo:  strings.Fields(conversation.Data["in_reply_to"]),
+		References: strings.Fields(conversation.Data["refs"]),
+	}
+}
+
+// promptDraft asks the user for the subject of a new mail or for the text of a mail with one.
+// The prompt replies to the originating message, so it stays in the same forum topic.
+func (d *delivery) promptDraft(localizer *i18n.Localizer, chatID int64, replyToMessageID int, draft *domain.Draft) error {
+	messageID, placeholderID, step := "TelegramComposeReply", "TelegramComposePlaceholder", stepMailBody
+	if draft.Subject == "" {
+		messageID, placeholderID, step = "TelegramComposeMail", "TelegramComposeSubjectPlaceholder", stepMailSubject
+	}
+
+	msg := tgbotapi.NewMessage(chatID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: messageID,
+		TemplateData: map[string]interface{}{
+			"From":    draft.From,
+			"To":      draft.To,
+			"Subject": tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, draft.Subject),
+		},
+	}))
+	msg.ParseMode = "MarkdownV2"
+	msg.ReplyToMessageID = replyToMessageID
+	msg.ReplyMarkup = forceReply(localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: placeholderID}))
+
+	return d.prompt(msg, draft.TelegramID, step, draftData(draft))
+}
+
+func (d *delivery) sendCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	args := strings.Fields(update.Message.CommandArguments())
+	if len(args) != 2 {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramSendUsage",
+		}))
+		msg.ParseMode = "MarkdownV2"
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	draft, err := d.service.ComposeMail(update.Message.From.ID, args[0], args[1])
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	if err := d.promptDraft(localizer, update.Message.Chat.ID, update.Message.MessageID, draft); err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	return nil
+}
+
+func (d *delivery) replyToMail(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	draft, err := d.service.ComposeReply(update.CallbackQuery.From.ID, args[0])
+	if err == nil {
+		err = d.promptDraft(
+			localizer,
+			update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			draft,
+		)
+	}
+	if err != nil {
+		messageID := errorMessageID(err)
+		if errors.Is(err, domain.ErrInvalidAddress) {
+			messageID = "TelegramReplyNotMasked"
+		}
+
+		d.answerCallbackAlert(localizer, update, messageID)
+		return err
+	}
+
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+
+	return nil
+}
+
+func (d *delivery) mailSubjectStep(localizer *i18n.Localizer, update tgbotapi.Update, conversation *domain.Conversation) error {
+	draft := conversationDraft(conversation)
+	draft.Subject = strings.TrimSpace(update.Message.Text)
+
+	if err := d.promptDraft(localizer, update.Message.Chat.ID, update.Message.MessageID, draft); err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	return nil
+}
+
+func (d *delivery) mailBodyStep(localizer *i18n.Localizer, update tgbotapi.Update, conversation *domain.Conversation) error {
+	if err := d.service.SendDraft(conversationDraft(conversation), update.Message.Text); err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	if err := d.conversations.finish(conversation.ChatID, conversation.TelegramID); err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramMailSent",
+	}))
+	msg.ReplyToMessageID = update.Message.MessageID
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
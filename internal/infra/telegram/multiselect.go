@@ -0,0 +1,358 @@
+package telegram
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+const (
+	// keyboardButtonsLimit keeps keyboards below the Telegram limit of 100 buttons.
+	keyboardButtonsLimit = 90
+	selectionPageSize    = 20
+	// selectionSummaryLimit keeps the per-item summary below the Telegram message length limit.
+	selectionSummaryLimit = 100
+
+	selectedMark   = "✅ "
+	unselectedMark = "▫️ "
+)
+
+// selectionAction is a bulk action offered below the items of a multi-select keyboard.
+type selectionAction struct {
+	name      string
+	messageID string
+	handle    func(localizer *i18n.Localizer, update tgbotapi.Update, selection *domain.Selection, ids []string) error
+}
+
+func (d *delivery) selectionActions(kind string) []*selectionAction {
+	switch kind {
+	case selectionKindBulk:
+		return []*selectionAction{
+			{"enable", "TelegramSelectionEnableButton", d.selectionStateAction(domain.MaskedEmailStateEnabled)},
+			{"disable", "TelegramSelectionDisableButton", d.selectionStateAction(domain.MaskedEmailStateDisabled)},
+			{"delete", "TelegramSelectionDeleteButton", d.selectionStateAction(domain.MaskedEmailStateDeleted)},
+			{"label", "TelegramSelectionLabelButton", d.promptSelectionLabel},
+		}
+	case selectionKindImport:
+		return []*selectionAction{
+			{"create", "TelegramSelectionCreateButton", d.importSelection},
+		}
+	case selectionKindCleanup:
+		return []*selectionAction{
+			{"disable", "TelegramSelectionDisableButton", d.selectionStateAction(domain.MaskedEmailStateDisabled)},
+			{"delete", "TelegramSelectionDeleteButton", d.selectionStateAction(domain.MaskedEmailStateDeleted)},
+		}
+	}
+
+	return nil
+}
+
+func maskedEmailSelectionItems(maskedEmails []*domain.MaskedEmail) []*domain.SelectionItem {
+	items := make([]*domain.SelectionItem, 0, len(maskedEmails))
+	for _, maskedEmail := range maskedEmails {
+		items = append(items, &domain.SelectionItem{
+			ID:    maskedEmail.ID,
+			Label: maskedEmail.Email,
+		})
+	}
+
+	return items
+}
+
+// selectionKeyboard renders the current page of items with their marks, paging and action buttons.
+func (d *delivery) selectionKeyboard(localizer *i18n.Localizer, selection *domain.Selection, actions []*selectionAction) tgbotapi.InlineKeyboardMarkup {
+	button := func(text string, args ...string) tgbotapi.InlineKeyboardButton {
+		return d.callbacks.button(text, selection.TelegramID, callbackSelection, args...)
+	}
+
+	pages := (len(selection.Items) + selectionPageSize - 1) / selectionPageSize
+	page := min(max(selection.Page, 0), max(pages-1, 0))
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, selectionPageSize+4)
+	selected := 0
+	for i, item := range selection.Items {
+		if item.Selected {
+			selected++
+		}
+
+		if i < page*selectionPageSize || i >= (page+1)*selectionPageSize {
+			continue
+		}
+
+		mark := unselectedMark
+		if item.Selected {
+			mark = selectedMark
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			button(mark+item.Label, "t", strconv.Itoa(i)),
+		))
+	}
+
+	if pages > 1 {
+		navigation := make([]tgbotapi.InlineKeyboardButton, 0, 3)
+		if page > 0 {
+			navigation = append(navigation, button("◀️", "p", strconv.Itoa(page-1)))
+		}
+		navigation = append(navigation, button(strconv.Itoa(page+1)+"/"+strconv.Itoa(pages), "p", strconv.Itoa(page)))
+		if page < pages-1 {
+			navigation = append(navigation, button("▶️", "p", strconv.Itoa(page+1)))
+		}
+		rows = append(rows, navigation)
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		button(
+			localizer.MustLocalize(&i18n.LocalizeConfig{
+				MessageID: "TelegramSelectionSelectAllButton",
+				TemplateData: map[string]interface{}{
+					"Selected": selected,
+					"Total":    len(selection.Items),
+				},
+			}),
+			"all",
+		),
+	))
+
+	for i := 0; i < len(actions); i += 2 {
+		row := make([]tgbotapi.InlineKeyboardButton, 0, 2)
+		for _, action := range actions[i:min(i+2, len(actions))] {
+			row = append(row, button(
+				localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: action.messageID}),
+				"a",
+				action.name,
+			))
+		}
+		rows = append(rows, row)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// sendSelection sends a multi-select keyboard and keeps its state on the server side.
+func (d *delivery) sendSelection(localizer *i18n.Localizer, chatID, telegramID int64, kind, text string, items []*domain.SelectionItem) error {
+	selection := &domain.Selection{
+		ChatID:     chatID,
+		TelegramID: telegramID,
+		Kind:       kind,
+		Items:      items,
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = d.selectionKeyboard(localizer, selection, d.selectionActions(kind))
+	sent, err := d.bot.Send(msg)
+	if err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+		return domain.ErrTelegramInternal
+	}
+
+	selection.MessageID = sent.MessageID
+	return d.service.CreateSelection(selection)
+}
+
+func (d *delivery) selectionCallback(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	message := update.CallbackQuery.Message
+	if message == nil {
+		return errors.New("no selection message")
+	}
+
+	selection, err := d.service.Selection(update.CallbackQuery.From.ID, message.Chat.ID, message.MessageID)
+	if errors.Is(err, domain.ErrNoSelection) {
+		d.answerCallbackAlert(localizer, update, "TelegramSelectionExpired")
+		return nil
+	}
+	if err != nil {
+		d.answerCallbackAlert(localizer, update, "TelegramError")
+		return err
+	}
+
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	actions := d.selectionActions(selection.Kind)
+	switch args[0] {
+	case "t":
+		if len(args) < 2 {
+			return d.invalidCallback(localizer, update)
+		}
+		i, err := strconv.Atoi(args[1])
+		if err != nil || i < 0 || i >= len(selection.Items) {
+			return d.invalidCallback(localizer, update)
+		}
+		selection.Items[i].Selected = !selection.Items[i].Selected
+	case "all":
+		// Select everything unless everything is already selected
+		selectAll := len(selection.SelectedIDs()) < len(selection.Items)
+		for _, item := range selection.Items {
+			item.Selected = selectAll
+		}
+	case "p":
+		if len(args) < 2 {
+			return d.invalidCallback(localizer, update)
+		}
+		page, err := strconv.Atoi(args[1])
+		if err != nil {
+			return d.invalidCallback(localizer, update)
+		}
+		selection.Page = page
+	case "a":
+		if len(args) < 2 {
+			return d.invalidCallback(localizer, update)
+		}
+
+		ids := selection.SelectedIDs()
+		if len(ids) == 0 {
+			d.answerCallbackAlert(localizer, update, "TelegramSelectionNothingSelected")
+			return nil
+		}
+
+		for _, action := range actions {
+			if action.name == args[1] {
+				return action.handle(localizer, update, selection, ids)
+			}
+		}
+		return d.invalidCallback(localizer, update)
+	default:
+		return d.invalidCallback(localizer, update)
+	}
+
+	if err := d.service.UpdateSelection(selection); err != nil {
+		d.answerCallbackAlert(localizer, update, "TelegramError")
+		return err
+	}
+	d.answerCallbackAlert(localizer, update, "")
+
+	edit := tgbotapi.NewEditMessageReplyMarkup(message.Chat.ID, message.MessageID, d.selectionKeyboard(localizer, selection, actions))
+	if _, err := d.bot.Request(edit); err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+	}
+
+	return nil
+}
+
+// finishSelection replaces the keyboard with a per-item summary of the action and forgets the selection.
+func (d *delivery) finishSelection(localizer *i18n.Localizer, selection *domain.Selection, actionMessageID string, result *domain.BatchResult) error {
+	succeeded := make(map[string]struct{}, len(result.Succeeded))
+	for _, id := range result.Succeeded {
+		succeeded[id] = struct{}{}
+	}
+
+	items := make([]map[string]interface{}, 0)
+	for _, item := range selection.Items {
+		if !item.Selected {
+			continue
+		}
+
+		if len(items) == selectionSummaryLimit {
+			break
+		}
+
+		_, ok := succeeded[item.ID]
+		items = append(items, map[string]interface{}{
+			"Label": item.Label,
+			"OK":    ok,
+		})
+	}
+
+	msg := tgbotapi.NewEditMessageText(
+		selection.ChatID,
+		selection.MessageID,
+		localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramSelectionSummary",
+			TemplateData: map[string]interface{}{
+				"Action":    localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: actionMessageID}),
+				"Succeeded": len(result.Succeeded),
+				"Failed":    len(result.Failed),
+				"Items":     items,
+				"Hidden":    len(selection.SelectedIDs()) - len(items),
+			},
+		}),
+	)
+	msg.ReplyMarkup = d.undoMarkup(localizer, selection.TelegramID, result)
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+	}
+
+	return d.service.DeleteSelection(selection.ChatID, selection.MessageID)
+}
+
+func (d *delivery) selectionStateAction(state domain.MaskedEmailState) func(*i18n.Localizer, tgbotapi.Update, *domain.Selection, []string) error {
+	actionMessageIDs := map[domain.MaskedEmailState]string{
+		domain.MaskedEmailStateEnabled:  "TelegramSelectionEnableButton",
+		domain.MaskedEmailStateDisabled: "TelegramSelectionDisableButton",
+		domain.MaskedEmailStateDeleted:  "TelegramSelectionDeleteButton",
+	}
+
+	return func(localizer *i18n.Localizer, update tgbotapi.Update, selection *domain.Selection, ids []string) error {
+		result, err := d.service.SetMaskedEmailsState(update.CallbackQuery.From.ID, ids, state)
+		if err != nil {
+			d.answerCallbackAlert(localizer, update, "TelegramError")
+			return err
+		}
+		d.answerCallbackAlert(localizer, update, "")
+
+		return d.finishSelection(localizer, selection, actionMessageIDs[state], result)
+	}
+}
+
+func (d *delivery) promptSelectionLabel(localizer *i18n.Localizer, update tgbotapi.Update, selection *domain.Selection, _ []string) error {
+	d.answerCallbackAlert(localizer, update, "")
+
+	msg := tgbotapi.NewMessage(selection.ChatID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramSelectionLabelPrompt",
+	}))
+	msg.ReplyToMessageID = selection.MessageID
+	msg.ReplyMarkup = forceReply("#label")
+
+	return d.prompt(msg, selection.TelegramID, stepSelectionLabel, map[string]string{
+		"message_id": strconv.Itoa(selection.MessageID),
+	})
+}
+
+// selectionLabelStep applies the label from the answer to the selected items.
+func (d *delivery) selectionLabelStep(localizer *i18n.Localizer, update tgbotapi.Update, conversation *domain.Conversation) error {
+	messageID, err := strconv.Atoi(conversation.Data["message_id"])
+	if err != nil {
+		return err
+	}
+
+	selection, err := d.service.Selection(conversation.TelegramID, conversation.ChatID, messageID)
+	if errors.Is(err, domain.ErrNoSelection) {
+		// The keyboard has expired or been used in the meantime
+		if err := d.conversations.finish(conversation.ChatID, conversation.TelegramID); err != nil {
+			return err
+		}
+		d.replyText(localizer, update.Message, "TelegramConversationExpired")
+		return nil
+	}
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	result, err := d.service.AddMaskedEmailsLabel(update.Message.From.ID, selection.SelectedIDs(), update.Message.Text)
+	if err != nil {
+		return d.replyLabelError(localizer, update, err)
+	}
+
+	if err := d.conversations.finish(conversation.ChatID, conversation.TelegramID); err != nil {
+		return err
+	}
+
+	return d.finishSelection(localizer, selection, "TelegramSelectionLabelButton", result)
+}
+
+// replyLabelError tells what's wrong with the label, the conversation stays to take another one.
+func (d *delivery) replyLabelError(localizer *i18n.Localizer, update tgbotapi.Update, err error) error {
+	if errors.Is(err, domain.ErrInvalidLabel) {
+		d.replyText(localizer, update.Message, "TelegramInvalidLabel")
+		return nil
+	}
+
+	d.replyText(localizer, update.Message, "TelegramError")
+	return err
+}
@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"errors"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+var newCommandFlags = []commandFlag{
+	{name: "for", hasValue: true},
+	{name: "prefix", hasValue: true},
+	{name: "desc", hasValue: true},
+	{name: "label", hasValue: true, repeated: true},
+	{name: "enabled"},
+}
+
+// parseNewCommand reads the options of /new, nil without an error means there are none.
+func parseNewCommand(arguments string) (*domain.MaskedEmailRequest, error) {
+	args, err := splitArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	flags, err := parseFlags(args, newCommandFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	first := func(name string) string {
+		if values := flags[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	request := &domain.MaskedEmailRequest{
+		ForDomain:   first("for"),
+		Prefix:      first("prefix"),
+		Description: first("desc"),
+		Labels:      flags["label"],
+	}
+	if _, ok := flags["enabled"]; ok {
+		request.State = domain.MaskedEmailStateEnabled
+	}
+
+	return request, nil
+}
+
+func (d *delivery) newCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	request, err := parseNewCommand(update.Message.CommandArguments())
+	var argErr *argumentError
+	if errors.As(err, &argErr) || request == nil {
+		text := localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramNewUsage"})
+		if argErr != nil {
+			text = localizer.MustLocalize(&i18n.LocalizeConfig{
+				MessageID:    argErr.messageID,
+				TemplateData: argErr.templateData,
+			}) + "\n\n" + text
+		}
+
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+		msg.ReplyToMessageID = update.Message.MessageID
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	maskedEmail, err := d.service.CreateMaskedEmail(update.Message.From.ID, request)
+	if errors.Is(err, domain.ErrDomainBlocked) {
+		d.sendDomainBlocked(localizer, update.Message)
+		return nil
+	}
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		if errors.Is(err, domain.ErrInvalidDomain) || errors.Is(err, domain.ErrInvalidPrefix) || errors.Is(err, domain.ErrInvalidLabel) {
+			return nil
+		}
+		return err
+	}
+
+	d.sendReply(d.newMaskedEmailMessage(localizer, update.Message.Chat.ID, update.Message.From.ID, maskedEmail), update.Message.From.ID, maskedEmail)
+
+	return nil
+}
+
+// newMaskedEmailMessage shows a created masked email, the keep button is only needed while it's pending.
+func (d *delivery) newMaskedEmailMessage(localizer *i18n.Localizer, chatID, telegramID int64, maskedEmail *domain.MaskedEmail) tgbotapi.MessageConfig {
+	if maskedEmail.State == domain.MaskedEmailStateEnabled {
+		msg := tgbotapi.NewMessage(chatID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramEmailWithoutDisclaimer",
+			TemplateData: map[string]interface{}{
+				"Email": maskedEmail.Email,
+			},
+		}))
+		msg.ParseMode = "MarkdownV2"
+		return msg
+	}
+
+	text, markup := emailMessage(localizer, d.callbacks, telegramID, maskedEmail)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "MarkdownV2"
+	msg.ReplyMarkup = markup
+	return msg
+}
@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+)
+
+func TestParseNewCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		want      *domain.MaskedEmailRequest
+		wantErr   string
+	}{
+		{"no options", "", nil, ""},
+		{"blank", "   ", nil, ""},
+		{
+			"every option",
+			`--for shop.com --prefix shop --desc "My shop" --label a --label=b --enabled`,
+			&domain.MaskedEmailRequest{
+				ForDomain:   "shop.com",
+				Prefix:      "shop",
+				Description: "My shop",
+				Labels:      []string{"a", "b"},
+				State:       domain.MaskedEmailStateEnabled,
+			},
+			"",
+		},
+		{
+			"phone keyboard",
+			`—desc «Online store» —for=shop.com`,
+			&domain.MaskedEmailRequest{ForDomain: "shop.com", Description: "Online store"},
+			"",
+		},
+		{"pending by default", "--prefix shop", &domain.MaskedEmailRequest{Prefix: "shop"}, ""},
+		{"positional", "shop.com", nil, "TelegramArgumentsUnexpected"},
+		{"unterminated", `--desc "My shop`, nil, "TelegramArgumentsUnterminatedQuote"},
+		{"missing value", "--for --enabled", nil, "TelegramArgumentsMissingValue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNewCommand(tt.arguments)
+			if messageID := argumentErrorID(err); messageID != tt.wantErr {
+				t.Fatalf("parseNewCommand(%q) error = %v, want %q", tt.arguments, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNewCommand(%q) = %+v, want %+v", tt.arguments, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+// rememberMaskedEmail binds the sent message to the masked email, so replies to it can act on the address.
+func (d *delivery) rememberMaskedEmail(telegramID int64, message tgbotapi.Message, maskedEmail *domain.MaskedEmail) {
+	if err := d.service.SaveMaskedEmailMessage(telegramID, message.Chat.ID, message.MessageID, maskedEmail); err != nil {
+		d.logger.Error("Error while saving a masked email message!", zap.Error(err))
+	}
+}
+
+// sendReply sends the message and remembers it for the masked email, so the replies can be chained.
+func (d *delivery) sendReply(msg tgbotapi.MessageConfig, telegramID int64, maskedEmail *domain.MaskedEmail) {
+	sent, err := d.bot.Send(msg)
+	if err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+		return
+	}
+
+	d.rememberMaskedEmail(telegramID, sent, maskedEmail)
+}
+
+// repliedMaskedEmail returns the masked email shown by the replied message,
+// nil without an error means there is none and the user has been told so.
+func (d *delivery) repliedMaskedEmail(localizer *i18n.Localizer, update tgbotapi.Update) (*domain.MaskedEmailMessage, error) {
+	messageID := ""
+	var message *domain.MaskedEmailMessage
+	if update.Message.ReplyToMessage == nil {
+		messageID = "TelegramReplyUsage"
+	} else {
+		var err error
+		message, err = d.service.MaskedEmailMessage(
+			update.Message.From.ID,
+			update.Message.Chat.ID,
+			update.Message.ReplyToMessage.MessageID,
+		)
+		if errors.Is(err, domain.ErrNoMaskedEmailMessage) {
+			messageID = "TelegramReplyNoMaskedEmail"
+		} else if err != nil {
+			d.sendError(localizer, update.Message.Chat.ID, err)
+			return nil, err
+		}
+	}
+
+	if messageID != "" {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: messageID,
+		}))
+		msg.ReplyToMessageID = update.Message.MessageID
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil, nil
+	}
+
+	return message, nil
+}
+
+func (d *delivery) disableCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	return d.setRepliedMaskedEmailState(localizer, update, domain.MaskedEmailStateDisabled, "TelegramEmailDisabled")
+}
+
+func (d *delivery) deleteCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	return d.setRepliedMaskedEmailState(localizer, update, domain.MaskedEmailStateDeleted, "TelegramEmailDeleted")
+}
+
+func (d *delivery) setRepliedMaskedEmailState(localizer *i18n.Localizer, update tgbotapi.Update, state domain.MaskedEmailState, doneMessageID string) error {
+	message, err := d.repliedMaskedEmail(localizer, update)
+	if message == nil {
+		return err
+	}
+
+	result, err := d.service.SetMaskedEmailsState(update.Message.From.ID, []string{message.MaskedEmailID}, state)
+	if err == nil && len(result.Failed) > 0 {
+		err = domain.ErrFastmailInternal
+	}
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: doneMessageID,
+	}))
+	msg.ReplyToMessageID = update.Message.ReplyToMessage.MessageID
+	if markup := d.undoMarkup(localizer, update.Message.From.ID, result); markup != nil {
+		msg.ReplyMarkup = markup
+	}
+	d.sendReply(msg, update.Message.From.ID, &domain.MaskedEmail{ID: message.MaskedEmailID, Email: message.Email})
+
+	return nil
+}
+
+func (d *delivery) noteCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	description := strings.TrimSpace(update.Message.CommandArguments())
+	if description == "" {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramNoteUsage",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	message, err := d.repliedMaskedEmail(localizer, update)
+	if message == nil {
+		return err
+	}
+
+	result, err := d.service.SetMaskedEmailDescription(update.Message.From.ID, message.MaskedEmailID, description)
+	if err == nil && len(result.Failed) > 0 {
+		err = domain.ErrFastmailInternal
+	}
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramNoteSaved",
+		TemplateData: map[string]interface{}{
+			"Email": message.Email,
+		},
+	}))
+	msg.ReplyToMessageID = update.Message.ReplyToMessage.MessageID
+	if markup := d.undoMarkup(localizer, update.Message.From.ID, result); markup != nil {
+		msg.ReplyMarkup = markup
+	}
+	d.sendReply(msg, update.Message.From.ID, &domain.MaskedEmail{ID: message.MaskedEmailID, Email: message.Email})
+
+	return nil
+}
+
+func (d *delivery) infoCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	message, err := d.repliedMaskedEmail(localizer, update)
+	if message == nil {
+		return err
+	}
+
+	details, err := d.service.MaskedEmailDetails(update.Message.From.ID, message.MaskedEmailID)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	text, markup := d.maskedEmailCard(localizer, update.Message.From.ID, details)
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+	msg.DisableWebPagePreview = true
+	msg.ReplyMarkup = markup
+	d.sendReply(msg, update.Message.From.ID, details.MaskedEmail)
+
+	return nil
+}
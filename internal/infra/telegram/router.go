@@ -0,0 +1,285 @@
+package telegram
+
+import (
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+// Update metrics per route, published with the rest of expvar.
+var (
+	updatesHandled  = expvar.NewMap("telegram_updates")
+	updatesFailed   = expvar.NewMap("telegram_update_errors")
+	updatesPanicked = expvar.NewMap("telegram_update_panics")
+	updatesSeconds  = expvar.NewMap("telegram_update_seconds")
+)
+
+// updateHandler handles an update in the language of its sender.
+type updateHandler func(localizer *i18n.Localizer, update tgbotapi.Update) error
+
+// middleware wraps the handler of a route, it runs for every update dispatched to the route.
+type middleware func(route *route, next updateHandler) updateHandler
+
+// route is a handler with the checks it runs behind.
+type route struct {
+	// name identifies the route in logs and metrics
+	name   string
+	handle updateHandler
+	// public routes work before Fastmail is connected
+	public bool
+	// private routes ignore group chats
+	private bool
+	// description is the message ID of the line of the command in the command menu and /help
+	description string
+}
+
+type routeOption func(*route)
+
+// public lets the route work before Fastmail is connected.
+func public() routeOption {
+	return func(r *route) {
+		r.public = true
+	}
+}
+
+// privateOnly keeps the route out of group chats.
+func privateOnly() routeOption {
+	return func(r *route) {
+		r.private = true
+	}
+}
+
+// described lists the command in the command menu and /help, messageID is its localized description.
+func described(messageID string) routeOption {
+	return func(r *route) {
+		r.description = messageID
+	}
+}
+
+// router dispatches updates to the handlers registered for them through the middleware.
+type router struct {
+	commands map[string]*route
+	// menu keeps described commands in the order they were registered
+	menu       []string
+	actions    map[callbackAction]callbackHandler
+	unknown    *route
+	callback   *route
+	inline     *route
+	document   *route
+	message    *route
+	middleware []middleware
+}
+
+func newRouter(middleware ...middleware) *router {
+	return &router{
+		commands:   make(map[string]*route),
+		actions:    make(map[callbackAction]callbackHandler),
+		middleware: middleware,
+	}
+}
+
+func newRoute(name string, handle updateHandler, options []routeOption) *route {
+	r := &route{
+		name:   name,
+		handle: handle,
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+func (r *router) command(name string, handle updateHandler, options ...routeOption) {
+	r.commands[name] = newRoute("command:"+name, handle, options)
+	if r.commands[name].description != "" {
+		r.menu = append(r.menu, name)
+	}
+}
+
+// unknownCommand handles commands nothing else is registered for.
+func (r *router) unknownCommand(handle updateHandler, options ...routeOption) {
+	r.unknown = newRoute("command", handle, options)
+}
+
+// callbackQuery handles button presses, they are dispatched further by the action behind the button.
+func (r *router) callbackQuery(handle updateHandler, options ...routeOption) {
+	r.callback = newRoute("callback", handle, options)
+}
+
+func (r *router) action(action callbackAction, handle callbackHandler) {
+	r.actions[action] = handle
+}
+
+func (r *router) inlineQuery(handle updateHandler, options ...routeOption) {
+	r.inline = newRoute("inline", handle, options)
+}
+
+func (r *router) documentMessage(handle updateHandler, options ...routeOption) {
+	r.document = newRoute("document", handle, options)
+}
+
+// textMessage handles messages that are neither commands nor documents.
+func (r *router) textMessage(handle updateHandler, options ...routeOption) {
+	r.message = newRoute("message", handle, options)
+}
+
+// resolve returns the route of the update, nil when nothing handles it.
+func (r *router) resolve(update tgbotapi.Update) *route {
+	switch {
+	case update.Message != nil:
+		var rt *route
+		switch {
+		case update.Message.IsCommand():
+			var ok bool
+			if rt, ok = r.commands[update.Message.Command()]; !ok {
+				rt = r.unknown
+			}
+		case update.Message.Document != nil:
+			rt = r.document
+		default:
+			rt = r.message
+		}
+
+		// Files with someone else's logins and the like have no business in group chats
+		if rt != nil && rt.private && !update.Message.Chat.IsPrivate() {
+			return nil
+		}
+		return rt
+	case update.CallbackQuery != nil:
+		return r.callback
+	case update.InlineQuery != nil:
+		return r.inline
+	}
+
+	return nil
+}
+
+// serve runs the handler of the update behind the middleware, the first one being the outermost.
+func (r *router) serve(update tgbotapi.Update) {
+	rt := r.resolve(update)
+	if rt == nil {
+		return
+	}
+
+	handle := rt.handle
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handle = r.middleware[i](rt, handle)
+	}
+
+	// The localizer is injected by the middleware
+	_ = handle(nil, update)
+}
+
+// updateSender returns the user the update comes from.
+func updateSender(update tgbotapi.Update) *tgbotapi.User {
+	switch {
+	case update.Message != nil:
+		return update.Message.From
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From
+	case update.InlineQuery != nil:
+		return update.InlineQuery.From
+	}
+
+	return nil
+}
+
+// recoverPanics keeps a failing handler from taking the update loop, and the bot, down with it.
+func (d *delivery) recoverPanics(rt *route, next updateHandler) updateHandler {
+	return func(localizer *i18n.Localizer, update tgbotapi.Update) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				updatesPanicked.Add(rt.name, 1)
+				d.logger.Error(
+					"Panic while handling an update!",
+					zap.Int("update_id", update.UpdateID),
+					zap.String("route", rt.name),
+					zap.Any("panic", p),
+					zap.Stack("stack"),
+				)
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+
+		return next(localizer, update)
+	}
+}
+
+func (d *delivery) logUpdates(rt *route, next updateHandler) updateHandler {
+	return func(localizer *i18n.Localizer, update tgbotapi.Update) error {
+		d.logger.Debug("Handling an update.", zap.Int("update_id", update.UpdateID), zap.String("route", rt.name))
+
+		err := next(localizer, update)
+		if err != nil {
+			d.logger.Error(
+				"Error while handling an update!",
+				zap.Int("update_id", update.UpdateID),
+				zap.String("route", rt.name),
+				zap.Error(err),
+			)
+		}
+
+		return err
+	}
+}
+
+func (d *delivery) measureUpdates(rt *route, next updateHandler) updateHandler {
+	return func(localizer *i18n.Localizer, update tgbotapi.Update) error {
+		start := time.Now()
+		err := next(localizer, update)
+
+		updatesHandled.Add(rt.name, 1)
+		updatesSeconds.AddFloat(rt.name, time.Since(start).Seconds())
+		if err != nil {
+			updatesFailed.Add(rt.name, 1)
+		}
+
+		return err
+	}
+}
+
+// localize injects the localizer for the language of the sender.
+func (d *delivery) localize(_ *route, next updateHandler) updateHandler {
+	return func(_ *i18n.Localizer, update tgbotapi.Update) error {
+		return next(d.languages.localizer(d.userLanguage(updateSender(update)).String()), update)
+	}
+}
+
+// authorize lets only users with a connected Fastmail account through routes that aren't public.
+func (d *delivery) authorize(rt *route, next updateHandler) updateHandler {
+	return func(localizer *i18n.Localizer, update tgbotapi.Update) error {
+		sender := updateSender(update)
+		if rt.public || sender == nil {
+			return next(localizer, update)
+		}
+
+		authorized, err := d.service.IsAuthorized(sender.ID)
+		if err != nil {
+			return err
+		}
+		if authorized {
+			return next(localizer, update)
+		}
+
+		switch {
+		case update.Message != nil:
+			// Group members chat with each other, only commands addressed to the bot are answered
+			if !update.Message.Chat.IsPrivate() && !update.Message.IsCommand() {
+				return nil
+			}
+			d.replyText(localizer, update.Message, "TelegramNotAuthorized")
+		case update.CallbackQuery != nil:
+			d.answerCallbackAlert(localizer, update, "TelegramNotAuthorized")
+		case update.InlineQuery != nil:
+			return d.answerInlineQuery(localizer, update, nil, domain.ErrNoToken)
+		}
+
+		return nil
+	}
+}
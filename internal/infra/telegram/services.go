@@ -0,0 +1,150 @@
+package telegram
+
+import (
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+const (
+	// servicesListLimit keeps the inventory below the Telegram message length limit.
+	servicesListLimit = 50
+	// duplicatesLimit caps how many keep-one proposals are sent at once.
+	duplicatesLimit = 10
+)
+
+func (d *delivery) servicesCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	groups, err := d.service.ServiceGroups(update.Message.From.ID)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	if len(groups) == 0 {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramServicesEmpty",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	services := make([]map[string]interface{}, 0, len(groups))
+	duplicates := make([]*domain.ServiceGroup, 0)
+	for _, group := range groups {
+		if group.HasDuplicates() {
+			duplicates = append(duplicates, group)
+		}
+
+		if len(services) == servicesListLimit {
+			continue
+		}
+
+		services = append(services, map[string]interface{}{
+			"Domain":    group.Domain,
+			"Total":     len(group.MaskedEmails),
+			"Enabled":   group.States[domain.MaskedEmailStateEnabled],
+			"Pending":   group.States[domain.MaskedEmailStatePending],
+			"Disabled":  group.States[domain.MaskedEmailStateDisabled],
+			"Duplicate": group.HasDuplicates(),
+		})
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramServices",
+		TemplateData: map[string]interface{}{
+			"Services":   services,
+			"Hidden":     len(groups) - len(services),
+			"Duplicates": len(duplicates),
+		},
+	}))
+	msg.DisableWebPagePreview = true
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	for i, group := range duplicates {
+		if i == duplicatesLimit {
+			break
+		}
+
+		active := group.Active()
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramServiceDuplicates",
+			TemplateData: map[string]interface{}{
+				"Domain": group.Domain,
+				"Count":  len(active),
+			},
+		}))
+		msg.DisableWebPagePreview = true
+
+		rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(active))
+		for j, maskedEmail := range active {
+			if j == keyboardButtonsLimit {
+				break
+			}
+
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				d.callbacks.button(
+					localizer.MustLocalize(&i18n.LocalizeConfig{
+						MessageID:    "TelegramServiceKeepButton",
+						TemplateData: map[string]interface{}{"Email": maskedEmail.Email},
+					}),
+					update.Message.From.ID,
+					callbackKeep,
+					maskedEmail.ID,
+				),
+			))
+		}
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (d *delivery) keepMaskedEmail(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	result, err := d.service.KeepMaskedEmail(update.CallbackQuery.From.ID, args[0])
+	if err != nil {
+		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramError",
+		}))
+		callback.ShowAlert = true
+		if _, err := d.bot.Request(callback); err != nil {
+			d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+		}
+		return err
+	}
+
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+
+	msg := tgbotapi.NewEditMessageText(
+		update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramServiceKept",
+			TemplateData: map[string]interface{}{
+				"Disabled": len(result.Succeeded),
+				"Failed":   len(result.Failed),
+			},
+		}),
+	)
+	msg.ReplyMarkup = d.undoMarkup(localizer, update.CallbackQuery.From.ID, result)
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+func statsTemplateData(stats *domain.Stats) map[string]interface{} {
+	createdPerMonth := make([]map[string]interface{}, 0, len(stats.CreatedPerMonth))
+	for _, month := range stats.CreatedPerMonth {
+		createdPerMonth = append(createdPerMonth, map[string]interface{}{
+			"Month": month.Month.Format("2006-01"),
+			"Count": month.Count,
+		})
+	}
+
+	total := 0
+	for state, count := range stats.States {
+		if state != domain.MaskedEmailStateDeleted {
+			total += count
+		}
+	}
+
+	return map[string]interface{}{
+		"Total":           total,
+		"Enabled":         stats.States[domain.MaskedEmailStateEnabled],
+		"Pending":         stats.States[domain.MaskedEmailStatePending],
+		"Disabled":        stats.States[domain.MaskedEmailStateDisabled],
+		"Deleted":         stats.States[domain.MaskedEmailStateDeleted],
+		"CreatedPerMonth": createdPerMonth,
+		"TopServices":     stats.TopServices,
+		"RecentTraffic":   stats.RecentTraffic,
+		"ExpiredUnused":   stats.ExpiredUnused,
+		"CreatedViaBot":   stats.Events[domain.MaskedEmailEventCreated],
+		"Rotated":         stats.Events[domain.MaskedEmailEventRotated],
+	}
+}
+
+func (d *delivery) statsCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	stats, err := d.service.Stats(update.Message.From.ID)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID:    "TelegramStats",
+		TemplateData: statsTemplateData(stats),
+	}))
+	msg.DisableWebPagePreview = true
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	created := 0
+	for _, month := range stats.CreatedPerMonth {
+		created += month.Count
+	}
+
+	// Nothing to draw without a single created address
+	if created == 0 {
+		return nil
+	}
+
+	chart, err := renderMonthsChart(stats.CreatedPerMonth)
+	if err != nil {
+		d.logger.Error("Error while rendering a chart!", zap.Error(err))
+		return nil
+	}
+
+	photo := tgbotapi.NewPhoto(update.Message.Chat.ID, tgbotapi.FileBytes{Name: "stats.png", Bytes: chart})
+	photo.Caption = localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramStatsChart",
+		TemplateData: map[string]interface{}{
+			"From": stats.CreatedPerMonth[0].Month.Format("2006-01"),
+			"To":   stats.CreatedPerMonth[len(stats.CreatedPerMonth)-1].Month.Format("2006-01"),
+		},
+	})
+	if _, err := d.bot.Send(photo); err != nil {
+		d.logger.Error("Error while sending a photo!", zap.Error(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+// undoButton returns a button restoring masked emails changed by the journaled operation,
+// cardID is set when the button sits on a masked email card that has to be refreshed after undo.
+func (d *delivery) undoButton(localizer *i18n.Localizer, telegramID, undoID int64, cardID string) tgbotapi.InlineKeyboardButton {
+	args := []string{strconv.FormatInt(undoID, 10)}
+	if cardID != "" {
+		args = append(args, cardID)
+	}
+
+	return d.callbacks.button(
+		localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "TelegramUndoButton"}),
+		telegramID,
+		callbackUndo,
+		args...,
+	)
+}
+
+// undoMarkup returns a keyboard with the undo button, nil when there is nothing to undo.
+func (d *delivery) undoMarkup(localizer *i18n.Localizer, telegramID int64, result *domain.BatchResult) *tgbotapi.InlineKeyboardMarkup {
+	if result.UndoID == 0 {
+		return nil
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(d.undoButton(localizer, telegramID, result.UndoID, "")))
+	return &markup
+}
+
+func (d *delivery) undo(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	undoID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return d.invalidCallback(localizer, update)
+	}
+
+	chatID := update.CallbackQuery.Message.Chat.ID
+	messageID := update.CallbackQuery.Message.MessageID
+
+	result, err := d.service.Undo(update.CallbackQuery.From.ID, undoID)
+	if err == nil && len(result.Failed) > 0 && len(result.Succeeded) == 0 {
+		err = domain.ErrFastmailInternal
+	}
+	if err != nil {
+		expired := errors.Is(err, domain.ErrNoUndo) || errors.Is(err, domain.ErrUndoExpired)
+		alertMessageID := "TelegramError"
+		if expired {
+			alertMessageID = "TelegramUndoExpired"
+		}
+
+		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: alertMessageID,
+		}))
+		callback.ShowAlert = true
+		if _, err := d.bot.Request(callback); err != nil {
+			d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+		}
+
+		if expired {
+			msg := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, d.callbacks.withoutAction(update.CallbackQuery.Message.ReplyMarkup, callbackUndo))
+			if _, err := d.bot.Send(msg); err != nil {
+				d.logger.Error("Error while editing a message!", zap.Error(err))
+			}
+			return nil
+		}
+		return err
+	}
+
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramUndoDone",
+		TemplateData: map[string]interface{}{
+			"Restored": len(result.Succeeded),
+			"Failed":   len(result.Failed),
+		},
+	}))
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+
+	// Cards show the state, so they are rendered again instead of just losing the button
+	if len(args) > 1 {
+		return d.refreshMaskedEmailCard(localizer, update.CallbackQuery.From.ID, chatID, messageID, args[1], 0)
+	}
+
+	msg := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, d.callbacks.withoutAction(update.CallbackQuery.Message.ReplyMarkup, callbackUndo))
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while editing a message!", zap.Error(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"go.uber.org/zap"
+)
+
+var unsubscriptionStatusMessageIDs = map[domain.UnsubscriptionStatus]string{
+	domain.UnsubscriptionStatusPending:  "TelegramUnsubscriptionPending",
+	domain.UnsubscriptionStatusHonoured: "TelegramUnsubscriptionHonoured",
+	domain.UnsubscriptionStatusIgnored:  "TelegramUnsubscriptionIgnored",
+}
+
+func (d *delivery) unsubscribe(localizer *i18n.Localizer, update tgbotapi.Update, args []string) error {
+	if len(args) < 1 {
+		return d.invalidCallback(localizer, update)
+	}
+
+	unsubscription, err := d.service.Unsubscribe(update.CallbackQuery.From.ID, args[0])
+	if err != nil {
+		messageID := errorMessageID(err)
+		switch {
+		case errors.Is(err, domain.ErrNoUnsubscribe):
+			messageID = "TelegramNoUnsubscribe"
+		case errors.Is(err, domain.ErrInvalidAddress):
+			messageID = "TelegramUnsubscribeNotMasked"
+		}
+
+		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: messageID,
+		}))
+		callback.ShowAlert = true
+		if _, err := d.bot.Request(callback); err != nil {
+			d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+		}
+		return err
+	}
+
+	callback := tgbotapi.NewCallback(update.CallbackQuery.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID: "TelegramUnsubscribed",
+		TemplateData: map[string]interface{}{
+			"Sender": unsubscription.Sender,
+		},
+	}))
+	if _, err := d.bot.Request(callback); err != nil {
+		d.logger.Error("Error while answering to the callback query!", zap.Error(err))
+	}
+
+	return nil
+}
+
+func (d *delivery) unsubscribesCommand(localizer *i18n.Localizer, update tgbotapi.Update) error {
+	unsubscriptions, err := d.service.Unsubscriptions(update.Message.From.ID)
+	if err != nil {
+		d.sendError(localizer, update.Message.Chat.ID, err)
+		return err
+	}
+
+	if len(unsubscriptions) == 0 {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: "TelegramUnsubscriptionsEmpty",
+		}))
+		if _, err := d.bot.Send(msg); err != nil {
+			d.logger.Error("Error while sending a message!", zap.Error(err))
+		}
+		return nil
+	}
+
+	lines := make([]string, 0, len(unsubscriptions))
+	for _, unsubscription := range unsubscriptions {
+		lines = append(lines, localizer.MustLocalize(&i18n.LocalizeConfig{
+			MessageID: unsubscriptionStatusMessageIDs[unsubscription.Status],
+			TemplateData: map[string]interface{}{
+				"Sender":      unsubscription.Sender,
+				"MaskedEmail": unsubscription.MaskedEmail,
+				"RequestedAt": unsubscription.RequestedAt.Format("2006-01-02"),
+			},
+		}))
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, strings.Join(lines, "\n"))
+	if _, err := d.bot.Send(msg); err != nil {
+		d.logger.Error("Error while sending a message!", zap.Error(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/L11R/masked-email-bot/internal/domain"
+	"go.uber.org/zap"
+)
+
+var errForbiddenAddress = errors.New("unsubscribe: forbidden address")
+
+type adapter struct {
+	logger *zap.Logger
+	config *Config
+	client *http.Client
+}
+
+func NewAdapter(logger *zap.Logger, config *Config) domain.Unsubscriber {
+	// Unsubscribe URLs come from incoming mail, so never let them reach internal hosts
+	dialer := &net.Dialer{
+		Timeout: config.Timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+
+			addr := addrPort.Addr().Unmap()
+			if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+				return errForbiddenAddress
+			}
+
+			return nil
+		},
+	}
+
+	return &adapter{
+		logger: logger,
+		config: config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				Proxy:       http.ProxyFromEnvironment,
+				DialContext: dialer.DialContext,
+			},
+		},
+	}
+}
+
+// OneClickUnsubscribe performs the RFC 8058 one-click unsubscribe POST request.
+func (a *adapter) OneClickUnsubscribe(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "https" {
+		return domain.ErrNoUnsubscribe
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		u.String(),
+		strings.NewReader("List-Unsubscribe=One-Click"),
+	)
+	if err != nil {
+		a.logger.Error("Error while creating a new HTTP request!", zap.Error(err))
+		return domain.ErrUnsubscribeInternal
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.logger.Error("Error while doing an HTTP request!", zap.Error(err))
+		return domain.ErrUnsubscribeInternal
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		a.logger.Error("Wrong status code!", zap.Int("status_code", resp.StatusCode))
+		return domain.ErrUnsubscribeInternal
+	}
+
+	return nil
+}
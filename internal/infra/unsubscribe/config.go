@@ -0,0 +1,7 @@
+package unsubscribe
+
+import "time"
+
+type Config struct {
+	Timeout time.Duration `env:"UNSUBSCRIBE_TIMEOUT,default=15s"`
+}
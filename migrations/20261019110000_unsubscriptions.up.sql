@@ -0,0 +1,10 @@
+create table unsubscriptions
+(
+    telegram_id  bigint   not null references users (telegram_id),
+    masked_email text     not null,
+    sender       text     not null,
+    method       text     not null,
+    requested_at datetime not null,
+    constraint unsubscriptions_pk
+        primary key (telegram_id, masked_email, sender)
+);
@@ -0,0 +1,6 @@
+drop table forum_topics;
+
+alter table users
+    drop column forum_polled_at;
+alter table users
+    drop column forum_chat_id;
@@ -0,0 +1,17 @@
+alter table users
+    add forum_chat_id bigint;
+alter table users
+    add forum_polled_at datetime;
+
+create table forum_topics
+(
+    telegram_id      bigint  not null references users (telegram_id),
+    masked_email_id  text    not null,
+    email            text    not null,
+    chat_id          bigint  not null,
+    thread_id        integer not null,
+    constraint forum_topics_pk
+        primary key (telegram_id, masked_email_id)
+);
+
+create index forum_topics_email_idx on forum_topics (telegram_id, email);
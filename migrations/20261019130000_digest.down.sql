@@ -0,0 +1,8 @@
+alter table users
+    drop column digest_sent_at;
+alter table users
+    drop column digest_hour;
+alter table users
+    drop column digest_timezone;
+alter table users
+    drop column digest_schedule;
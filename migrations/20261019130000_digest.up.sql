@@ -0,0 +1,8 @@
+alter table users
+    add digest_schedule text default 'off' not null;
+alter table users
+    add digest_timezone text default 'UTC' not null;
+alter table users
+    add digest_hour integer default 9 not null;
+alter table users
+    add digest_sent_at datetime;
@@ -0,0 +1 @@
+drop table notified_breaches;
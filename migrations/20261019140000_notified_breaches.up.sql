@@ -0,0 +1,8 @@
+create table notified_breaches
+(
+    telegram_id bigint   not null references users (telegram_id),
+    breach_name text     not null,
+    notified_at datetime not null,
+    constraint notified_breaches_pk
+        primary key (telegram_id, breach_name)
+);
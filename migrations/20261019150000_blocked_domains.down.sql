@@ -0,0 +1 @@
+drop table blocked_domains;
@@ -0,0 +1,8 @@
+create table blocked_domains
+(
+    telegram_id bigint   not null references users (telegram_id),
+    domain      text     not null,
+    blocked_at  datetime not null,
+    constraint blocked_domains_pk
+        primary key (telegram_id, domain)
+);
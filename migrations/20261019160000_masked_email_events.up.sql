@@ -0,0 +1,12 @@
+create table masked_email_events
+(
+    id              integer primary key autoincrement,
+    telegram_id     bigint   not null references users (telegram_id),
+    masked_email_id text     not null,
+    event           text     not null,
+    details         text     not null default '',
+    created_at      datetime not null
+);
+
+create index masked_email_events_masked_email_idx
+    on masked_email_events (telegram_id, masked_email_id);
@@ -0,0 +1,2 @@
+drop table selection_items;
+drop table selections;
@@ -0,0 +1,27 @@
+create table selections
+(
+    chat_id           bigint   not null,
+    message_id        integer  not null,
+    telegram_id       bigint   not null references users (telegram_id),
+    kind              text     not null,
+    page              integer  not null default 0,
+    prompt_message_id integer,
+    created_at        datetime not null,
+    constraint selections_pk
+        primary key (chat_id, message_id)
+);
+
+create index selections_prompt_idx
+    on selections (chat_id, prompt_message_id);
+
+create table selection_items
+(
+    chat_id    bigint  not null,
+    message_id integer not null,
+    position   integer not null,
+    item_id    text    not null,
+    label      text    not null,
+    selected   boolean not null default false,
+    constraint selection_items_pk
+        primary key (chat_id, message_id, position)
+);
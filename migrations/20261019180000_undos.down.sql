@@ -0,0 +1,2 @@
+drop table undo_items;
+drop table undos;
@@ -0,0 +1,18 @@
+create table undos
+(
+    id          integer  not null
+        constraint undos_pk
+            primary key autoincrement,
+    telegram_id bigint   not null references users (telegram_id),
+    created_at  datetime not null
+);
+
+create table undo_items
+(
+    undo_id         integer not null references undos (id),
+    masked_email_id text    not null,
+    state           text    not null,
+    description     text    not null,
+    constraint undo_items_pk
+        primary key (undo_id, masked_email_id)
+);
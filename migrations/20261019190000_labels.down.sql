@@ -0,0 +1,4 @@
+alter table undos
+    drop column label;
+
+drop table masked_email_labels;
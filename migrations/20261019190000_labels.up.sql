@@ -0,0 +1,15 @@
+create table masked_email_labels
+(
+    telegram_id     bigint   not null references users (telegram_id),
+    masked_email_id text     not null,
+    label           text     not null,
+    created_at      datetime not null,
+    constraint masked_email_labels_pk
+        primary key (telegram_id, masked_email_id, label)
+);
+
+create index masked_email_labels_label_idx
+    on masked_email_labels (telegram_id, label);
+
+alter table undos
+    add column label text not null default '';
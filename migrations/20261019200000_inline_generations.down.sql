@@ -0,0 +1 @@
+drop table inline_generations;
@@ -0,0 +1,9 @@
+create table inline_generations
+(
+    token       text     not null
+        constraint inline_generations_pk
+            primary key,
+    telegram_id bigint   not null references users (telegram_id),
+    target      text     not null,
+    created_at  datetime not null
+);
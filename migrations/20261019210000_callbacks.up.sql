@@ -0,0 +1,15 @@
+create table callbacks
+(
+    token       text     not null
+        constraint callbacks_pk
+            primary key,
+    action      text     not null,
+    args        text     not null,
+    telegram_id bigint   not null,
+    expires_at  datetime not null
+);
+
+create index callbacks_expires_at_idx
+    on callbacks (expires_at);
+
+drop table inline_generations;
@@ -0,0 +1 @@
+drop table masked_email_messages;
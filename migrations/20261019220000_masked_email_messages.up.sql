@@ -0,0 +1,11 @@
+create table masked_email_messages
+(
+    chat_id         bigint   not null,
+    message_id      integer  not null,
+    telegram_id     bigint   not null references users (telegram_id),
+    masked_email_id text     not null,
+    email           text     not null,
+    created_at      datetime not null,
+    constraint masked_email_messages_pk
+        primary key (chat_id, message_id)
+);
@@ -0,0 +1,21 @@
+alter table selections
+    add column prompt_message_id integer;
+
+create index selections_prompt_idx
+    on selections (chat_id, prompt_message_id);
+
+create table drafts
+(
+    chat_id     bigint not null,
+    message_id  integer not null,
+    telegram_id bigint not null references users (telegram_id),
+    from_email  text   not null,
+    to_email    text   not null,
+    subject     text   not null default '',
+    in_reply_to text   not null default '',
+    refs        text   not null default '',
+    constraint drafts_pk
+        primary key (chat_id, message_id)
+);
+
+drop table conversations;
@@ -0,0 +1,21 @@
+create table conversations
+(
+    chat_id           bigint   not null,
+    telegram_id       bigint   not null references users (telegram_id),
+    step              text     not null,
+    data              text     not null,
+    prompt_message_id integer  not null,
+    expires_at        datetime not null,
+    constraint conversations_pk
+        primary key (chat_id, telegram_id)
+);
+
+create index conversations_expires_at_idx
+    on conversations (expires_at);
+
+drop table drafts;
+
+drop index selections_prompt_idx;
+
+alter table selections
+    drop column prompt_message_id;
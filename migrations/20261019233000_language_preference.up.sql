@@ -0,0 +1,2 @@
+alter table users
+    add lang_chosen boolean default false not null;
@@ -0,0 +1 @@
+drop index masked_email_messages_created_at_idx;
@@ -0,0 +1,2 @@
+create index masked_email_messages_created_at_idx
+    on masked_email_messages (created_at);
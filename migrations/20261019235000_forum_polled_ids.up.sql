@@ -0,0 +1,2 @@
+alter table users
+    add forum_polled_ids text;